@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeedApartments(t *testing.T) {
+	anchor := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		seed          int64
+		count, owners int
+	}{
+		{name: "default", seed: 1, count: 50, owners: 10},
+		{name: "one owner", seed: 7, count: 20, owners: 1},
+		{name: "none", seed: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := seedApartments(tt.seed, tt.count, tt.owners, anchor)
+			if again := seedApartments(tt.seed, tt.count, tt.owners, anchor); !reflect.DeepEqual(generated, again) {
+				t.Fatal("got different apartments from the same seed")
+			}
+			if len(generated) != tt.count {
+				t.Fatalf("got %d apartments, want %d", len(generated), tt.count)
+			}
+			owners := make(map[string]bool, tt.owners)
+			for i := 0; i < tt.owners; i++ {
+				owners[seedObjectID(tt.seed, fmt.Sprintf("owner:%d", i)).Hex()] = true
+			}
+			addresses := make(map[string]bool, tt.count)
+			for i, apartment := range generated {
+				if apartment.ID != seedObjectID(tt.seed, fmt.Sprintf("apartment:%d", i)) {
+					t.Errorf("apartment %d: got id %s, want the one booking's seed derives", i, apartment.ID.Hex())
+				}
+				if !owners[apartment.Owner] {
+					t.Errorf("apartment %d: got owner %s, want one of the %d seeded owners", i, apartment.Owner, tt.owners)
+				}
+				if addresses[apartment.Address] {
+					t.Errorf("apartment %d: got address %q twice", i, apartment.Address)
+				}
+				addresses[apartment.Address] = true
+				if apartment.Created.After(anchor) {
+					t.Errorf("apartment %d: got created %s, want at most the anchor", i, apartment.Created)
+				}
+			}
+		})
+	}
+}
+
+func TestSeedApartmentsSeeds(t *testing.T) {
+	anchor := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	first, second := seedApartments(1, 10, 3, anchor), seedApartments(2, 10, 3, anchor)
+	for i := range first {
+		if first[i].ID == second[i].ID || first[i].ExternalRef == second[i].ExternalRef {
+			t.Errorf("apartment %d: got the same id or reference from different seeds", i)
+		}
+	}
+}
+
+// TestSeedObjectID pins the derived ids, booking's seed command has the same test.
+func TestSeedObjectID(t *testing.T) {
+	if got, want := seedObjectID(1, "apartment:0").Hex(), "cc35391657502940ba024cd2"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package apartments
+
+import (
+	"strings"
+)
+
+const defaultLanguage = "en"
+
+// Amenity is an entry of the amenities catalog. Code is stable and is what apartments store,
+// Labels are keyed by language.
+type Amenity struct {
+	Code     string
+	Category string
+	Labels   map[string]string
+}
+
+// LocalizedAmenity is an amenity with its label in the requested language.
+type LocalizedAmenity struct {
+	Code     string `json:"code"`
+	Category string `json:"category"`
+	Label    string `json:"label"`
+}
+
+// amenitiesCatalog is ordered the way amenities are shown to users. Never rename or remove a code,
+// stored apartments refer to them.
+var amenitiesCatalog = []Amenity{
+	{Code: "wifi", Category: "essentials", Labels: map[string]string{"en": "Wi-Fi", "de": "WLAN"}},
+	{Code: "kitchen", Category: "essentials", Labels: map[string]string{"en": "Kitchen", "de": "Küche"}},
+	{Code: "heating", Category: "essentials", Labels: map[string]string{"en": "Heating", "de": "Heizung"}},
+	{Code: "air_conditioning", Category: "essentials", Labels: map[string]string{"en": "Air conditioning", "de": "Klimaanlage"}},
+	{Code: "washer", Category: "essentials", Labels: map[string]string{"en": "Washer", "de": "Waschmaschine"}},
+	{Code: "dryer", Category: "essentials", Labels: map[string]string{"en": "Dryer", "de": "Trockner"}},
+	{Code: "dishwasher", Category: "essentials", Labels: map[string]string{"en": "Dishwasher", "de": "Geschirrspüler"}},
+	{Code: "tv", Category: "essentials", Labels: map[string]string{"en": "TV", "de": "Fernseher"}},
+	{Code: "workspace", Category: "essentials", Labels: map[string]string{"en": "Dedicated workspace", "de": "Arbeitsplatz"}},
+	{Code: "parking", Category: "facilities", Labels: map[string]string{"en": "Free parking", "de": "Kostenloser Parkplatz"}},
+	{Code: "elevator", Category: "facilities", Labels: map[string]string{"en": "Elevator", "de": "Aufzug"}},
+	{Code: "balcony", Category: "facilities", Labels: map[string]string{"en": "Balcony", "de": "Balkon"}},
+	{Code: "pool", Category: "facilities", Labels: map[string]string{"en": "Pool", "de": "Pool"}},
+	{Code: "gym", Category: "facilities", Labels: map[string]string{"en": "Gym", "de": "Fitnessraum"}},
+	{Code: "pets_allowed", Category: "rules", Labels: map[string]string{"en": "Pets allowed", "de": "Haustiere erlaubt"}},
+	{Code: "smoking_allowed", Category: "rules", Labels: map[string]string{"en": "Smoking allowed", "de": "Rauchen erlaubt"}},
+	{Code: "family_friendly", Category: "rules", Labels: map[string]string{"en": "Family friendly", "de": "Familienfreundlich"}},
+	{Code: "wheelchair_accessible", Category: "accessibility", Labels: map[string]string{
+		"en": "Wheelchair accessible",
+		"de": "Rollstuhlgerecht",
+	}},
+	{Code: "smoke_alarm", Category: "safety", Labels: map[string]string{"en": "Smoke alarm", "de": "Rauchmelder"}},
+	{Code: "first_aid_kit", Category: "safety", Labels: map[string]string{"en": "First aid kit", "de": "Erste-Hilfe-Set"}},
+}
+
+var amenityCodes = func() map[string]bool {
+	codes := make(map[string]bool, len(amenitiesCatalog))
+	for _, amenity := range amenitiesCatalog {
+		codes[amenity.Code] = true
+	}
+	return codes
+}()
+
+// LocalizeAmenities returns the catalog with labels in language, falling back to English.
+func LocalizeAmenities(language string) []LocalizedAmenity {
+	language = strings.ToLower(strings.TrimSpace(language))
+	localized := make([]LocalizedAmenity, 0, len(amenitiesCatalog))
+	for _, amenity := range amenitiesCatalog {
+		label, ok := amenity.Labels[language]
+		if !ok {
+			label = amenity.Labels[defaultLanguage]
+		}
+		localized = append(localized, LocalizedAmenity{Code: amenity.Code, Category: amenity.Category, Label: label})
+	}
+	return localized
+}
+
+// validateAmenities checks that every code is in the catalog, field is reported in the *ValidationError.
+func validateAmenities(field string, codes []string) error {
+	for _, code := range codes {
+		if !amenityCodes[code] {
+			return &ValidationError{Field: field, Reason: "unknown amenity " + code}
+		}
+	}
+	return nil
+}
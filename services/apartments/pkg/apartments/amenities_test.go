@@ -0,0 +1,46 @@
+package apartments
+
+import "testing"
+
+func TestLocalizeAmenities(t *testing.T) {
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{language: "en", want: "Wi-Fi"},
+		{language: " DE ", want: "WLAN"},
+		{language: "pt", want: "Wi-Fi"},
+		{language: "", want: "Wi-Fi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			amenities := LocalizeAmenities(tt.language)
+			if len(amenities) != len(amenitiesCatalog) {
+				t.Fatalf("got %d amenities, want %d", len(amenities), len(amenitiesCatalog))
+			}
+			if wifi := amenities[0]; wifi.Code != "wifi" || wifi.Label != tt.want {
+				t.Errorf("got %+v, want wifi labelled %q", wifi, tt.want)
+			}
+		})
+	}
+	for _, amenity := range amenitiesCatalog {
+		if amenity.Labels[defaultLanguage] == "" {
+			t.Errorf("%s has no %s label", amenity.Code, defaultLanguage)
+		}
+	}
+}
+
+func TestUnknownAmenities(t *testing.T) {
+	details := validDetails()
+	details.Amenities = []string{"wifi", "Sauna"}
+	assertValidationField(t, details.Normalize(), "amenities")
+
+	list := ListFilter{Amenities: []string{"sauna"}}
+	assertValidationField(t, list.Normalize(), "amenities")
+
+	search := SearchFilter{Amenities: []string{"PARKING", "sauna"}}
+	assertValidationField(t, search.Normalize(), "amenities")
+
+	known := ListFilter{Amenities: []string{" Pool", "pool", "pets_allowed"}}
+	assertValidationField(t, known.Normalize(), "")
+}
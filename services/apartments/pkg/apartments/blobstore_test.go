@@ -0,0 +1,68 @@
+package apartments
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalBlobStorePath(t *testing.T) {
+	store := NewLocalBlobStore("/var/media", "http://localhost/media/")
+	tests := []struct {
+		key     string
+		want    string
+		wantErr error
+	}{
+		{key: "apartments/a/p/original.jpg", want: filepath.FromSlash("/var/media/apartments/a/p/original.jpg")},
+		{key: "", wantErr: ErrInvalidBlobKey},
+		{key: "/apartments/a.jpg", wantErr: ErrInvalidBlobKey},
+		{key: "../etc/passwd", wantErr: ErrInvalidBlobKey},
+		{key: "apartments/../../etc/passwd", wantErr: ErrInvalidBlobKey},
+		{key: "apartments/./a.jpg", wantErr: ErrInvalidBlobKey},
+		{key: "apartments//a.jpg", wantErr: ErrInvalidBlobKey},
+		{key: "apartments/a/", wantErr: ErrInvalidBlobKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := store.path(tt.key)
+			if err != tt.wantErr || got != tt.want {
+				t.Errorf("got %q and error %v, want %q and %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+	if got := store.URL("apartments/a.jpg"); got != "http://localhost/media/apartments/a.jpg" {
+		t.Errorf("got URL %q", got)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocalBlobStore(filepath.Join(root, "media"), "/media")
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "../escaped.jpg", strings.NewReader("photo")); err != ErrInvalidBlobKey {
+		t.Errorf("put outside of the root: got error %v, want %v", err, ErrInvalidBlobKey)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.jpg")); !os.IsNotExist(err) {
+		t.Errorf("a blob was written outside of the root")
+	}
+
+	if err := store.Put(ctx, "apartments/a/original.jpg", strings.NewReader("photo")); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(root, "media", "apartments", "a", "original.jpg")
+	if data, err := os.ReadFile(filename); err != nil || string(data) != "photo" {
+		t.Errorf("got %q and error %v", data, err)
+	}
+	if err := store.Delete(ctx, "apartments/a/original.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "apartments/a/original.jpg"); err != nil {
+		t.Errorf("deleting a missing blob: got error %v", err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("blob is still there")
+	}
+}
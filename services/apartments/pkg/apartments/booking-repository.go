@@ -0,0 +1,95 @@
+package apartments
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"contracts/pkg/contracts"
+	nats_tracing "contracts/pkg/nats-tracing"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrCouldNotGetResponseFromBooking = errors.New("could not get response from the booking service, wrong response format")
+
+type BookingRepositoryNATS struct {
+	nc     *nats.Conn
+	tracer trace.TracerProvider
+	// contentType is the encoding of requests, see contracts.SupportedContentType.
+	contentType string
+	traceOption nats_tracing.TracerOption
+}
+
+func NewBookingRepository(
+	nc *nats.Conn,
+	tracer trace.TracerProvider,
+	contentType string,
+	traceOptions ...nats_tracing.TracerOption,
+) *BookingRepositoryNATS {
+	return &BookingRepositoryNATS{nc: nc, tracer: tracer, contentType: contentType, traceOption: nats_tracing.Options(traceOptions...)}
+}
+
+func (b *BookingRepositoryNATS) HasFutureReservations(ctx context.Context, apartmentID string) (bool, error) {
+	publisher := contracts.NewPublisher(
+		b.nc,
+		contracts.SubjectHasFutureReservations,
+		contracts.NewRequestEncoder(b.contentType),
+		contracts.DecodeHasFutureReservationsResponse,
+		nats_tracing.NATSPublisherTrace(b.tracer, b.traceOption, nats_tracing.SetName("check future reservations")),
+	)
+	res, err := publisher.Endpoint()(ctx, contracts.HasFutureReservationsRequest{ApartmentID: apartmentID})
+	if err != nil {
+		return false, err
+	}
+	response, ok := res.(contracts.HasFutureReservationsResponse)
+	if !ok {
+		return false, ErrCouldNotGetResponseFromBooking
+	}
+	if response.Err != nil {
+		return false, response.Err
+	}
+	return response.HasFutureReservations, nil
+}
+
+// GetBusyApartmentIDs asks the booking service, in a single request, which apartments have reservations
+// overlapping the given window.
+func (b *BookingRepositoryNATS) GetBusyApartmentIDs(ctx context.Context, checkIn, checkOut time.Time) ([]string, error) {
+	publisher := contracts.NewPublisher(
+		b.nc,
+		contracts.SubjectGetBusyApartments,
+		contracts.NewRequestEncoder(b.contentType),
+		contracts.DecodeGetBusyApartmentsResponse,
+		nats_tracing.NATSPublisherTrace(b.tracer, b.traceOption, nats_tracing.SetName("get busy apartments")),
+	)
+	res, err := publisher.Endpoint()(ctx, contracts.GetBusyApartmentsRequest{CheckIn: checkIn, CheckOut: checkOut})
+	if err != nil {
+		return nil, err
+	}
+	response, ok := res.(contracts.GetBusyApartmentsResponse)
+	if !ok {
+		return nil, ErrCouldNotGetResponseFromBooking
+	}
+	if response.Err != nil {
+		return nil, response.Err
+	}
+	return response.ApartmentIDs, nil
+}
+
+// bookingUnavailable tells whether err means the booking service did not answer in time or could not serve
+// the request for now, rather than refused it.
+func bookingUnavailable(err error) bool {
+	var replyErr *contracts.ReplyError
+	if errors.As(err, &replyErr) {
+		return replyErr.Code == contracts.CodeUnavailable
+	}
+	for _, unavailable := range []error{
+		nats.ErrTimeout, nats.ErrNoResponders, nats.ErrConnectionClosed, nats.ErrConnectionDraining, context.DeadlineExceeded,
+	} {
+		if errors.Is(err, unavailable) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package apartments
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of the files import reads and export writes.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// AmenitiesSeparator separates amenity codes inside a single CSV column.
+const AmenitiesSeparator = "|"
+
+const (
+	maxExternalRefLength = 100
+	maxNDJSONLineSize    = 1 << 20
+)
+
+// ImportColumns are the CSV columns understood by import, export writes them too so that its output can be imported back.
+var ImportColumns = []string{
+	"externalRef", "owner", "status", "title", "description", "address", "city",
+	"price", "capacity", "bedrooms", "amenities", "instantBook", "lat", "lng",
+}
+
+// ImportRow is an apartment as loaded by the import command. Status is optional, rows without it
+// create drafts and keep the status of existing apartments.
+type ImportRow struct {
+	ExternalRef string        `json:"externalRef"`
+	Owner       string        `json:"owner"`
+	Status      ListingStatus `json:"status"`
+	ApartmentDetails
+}
+
+// Apartment validates the row, returning a *ValidationError for the first invalid field, and builds
+// the apartment to upsert.
+func (r *ImportRow) Apartment() (*Apartment, error) {
+	r.ExternalRef = strings.TrimSpace(r.ExternalRef)
+	r.Owner = strings.TrimSpace(r.Owner)
+	switch {
+	case r.ExternalRef == "":
+		return nil, &ValidationError{Field: "externalRef", Reason: "is required"}
+	case len(r.ExternalRef) > maxExternalRefLength:
+		return nil, &ValidationError{Field: "externalRef", Reason: fmt.Sprintf("must be at most %d characters long", maxExternalRefLength)}
+	case r.Owner == "":
+		return nil, &ValidationError{Field: "owner", Reason: "is required"}
+	case r.Status != "" && !r.Status.Valid():
+		return nil, &ValidationError{Field: "status", Reason: "must be one of draft, pending_review, published, suspended, archived"}
+	}
+	if err := r.ApartmentDetails.Normalize(); err != nil {
+		return nil, err
+	}
+
+	apartment := &Apartment{ExternalRef: r.ExternalRef, Owner: r.Owner, Status: r.Status, Created: time.Now()}
+	r.ApartmentDetails.apply(apartment)
+	return apartment, nil
+}
+
+// ImportSummary counts the rows of an import, Failed ones included.
+type ImportSummary struct {
+	Rows, Created, Updated, Failed int
+}
+
+// RowError is a row import skipped, Row being its number in the input.
+type RowError struct {
+	Row         int
+	ExternalRef string
+	Err         error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d (ref %q): %v", e.Row, e.ExternalRef, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// UpsertFunc saves an imported apartment by owner and external reference, reporting whether it was created.
+// See MongoRepositoryApartments.UpsertApartmentByExternalRef.
+type UpsertFunc func(ctx context.Context, apartment *Apartment) (created bool, err error)
+
+// ImportApartments upserts the apartments of the format rows read from r, rows without an owner belong to owner.
+// A nil upsert only validates the input. Invalid rows, rows repeating the reference of an earlier row and rows
+// upsert fails on are passed to report and skipped, only unreadable input stops the import.
+func ImportApartments(ctx context.Context, r io.Reader, format, owner string, upsert UpsertFunc, report func(*RowError)) (ImportSummary, error) { //nolint:lll
+	summary := ImportSummary{}
+	seen := make(map[string]int)
+	fail := func(rowNumber int, ref string, err error) {
+		summary.Failed++
+		report(&RowError{Row: rowNumber, ExternalRef: ref, Err: err})
+	}
+	err := ReadImportRows(r, format, func(rowNumber int, row ImportRow, rowErr error) error {
+		summary.Rows++
+		if rowErr != nil {
+			fail(rowNumber, row.ExternalRef, rowErr)
+			return nil
+		}
+		if row.Owner == "" {
+			row.Owner = owner
+		}
+		apartment, err := row.Apartment()
+		if err != nil {
+			fail(rowNumber, row.ExternalRef, err)
+			return nil
+		}
+		key := apartment.Owner + "\x00" + apartment.ExternalRef
+		if first, ok := seen[key]; ok {
+			fail(rowNumber, row.ExternalRef, fmt.Errorf("duplicates row %d", first))
+			return nil
+		}
+		seen[key] = rowNumber
+		if upsert == nil {
+			return nil
+		}
+
+		created, err := upsert(ctx, apartment)
+		switch {
+		case err != nil:
+			fail(rowNumber, row.ExternalRef, err)
+		case created:
+			summary.Created++
+		default:
+			summary.Updated++
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// ReadImportRows calls fn for every row of r with its number. Rows that can not be parsed are passed
+// with an error, errors returned by fn and unreadable input stop reading.
+func ReadImportRows(r io.Reader, format string, fn func(rowNumber int, row ImportRow, err error) error) error {
+	switch format {
+	case FormatCSV:
+		return readCSVRows(r, fn)
+	case FormatNDJSON:
+		return readNDJSONRows(r, fn)
+	default:
+		return fmt.Errorf("unknown format %q, must be csv or ndjson", format)
+	}
+}
+
+func readNDJSONRows(r io.Reader, fn func(rowNumber int, row ImportRow, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var row ImportRow
+		err := json.Unmarshal(scanner.Bytes(), &row)
+		if err = fn(line, row, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readCSVRows reads rows with a header naming ImportColumns, unknown columns such as id are ignored.
+// Rows are numbered the way spreadsheets do, the header being row 1.
+func readCSVRows(r io.Reader, fn func(rowNumber int, row ImportRow, err error) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("could not read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for line := 2; ; line++ {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			return nil
+		}
+		var row ImportRow
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(readErr, &parseErr):
+			err = readErr
+		case readErr != nil:
+			return readErr
+		default:
+			err = parseCSVRow(columns, record, &row)
+		}
+		if err = fn(line, row, err); err != nil {
+			return err
+		}
+	}
+}
+
+func parseCSVRow(columns map[string]int, record []string, row *ImportRow) error {
+	value := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row.ExternalRef = value("externalRef")
+	row.Owner = value("owner")
+	row.Status = ListingStatus(value("status"))
+	row.Title = value("title")
+	row.Description = value("description")
+	row.Address = value("address")
+	row.City = value("city")
+	if amenities := value("amenities"); amenities != "" {
+		row.Amenities = strings.Split(amenities, AmenitiesSeparator)
+	}
+
+	var err error
+	if row.Price, err = csvFloat(value("price"), "price"); err != nil {
+		return err
+	}
+	if row.Capacity, err = csvInt(value("capacity"), "capacity"); err != nil {
+		return err
+	}
+	if row.Bedrooms, err = csvInt(value("bedrooms"), "bedrooms"); err != nil {
+		return err
+	}
+	if instantBook := value("instantBook"); instantBook != "" {
+		if row.InstantBook, err = strconv.ParseBool(instantBook); err != nil {
+			return &ValidationError{Field: "instantBook", Reason: "must be true or false"}
+		}
+	}
+	if row.Latitude, err = csvOptionalFloat(value("lat"), "lat"); err != nil {
+		return err
+	}
+	row.Longitude, err = csvOptionalFloat(value("lng"), "lng")
+	return err
+}
+
+func csvFloat(value, field string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &ValidationError{Field: field, Reason: "must be a number"}
+	}
+	return f, nil
+}
+
+func csvOptionalFloat(value, field string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := csvFloat(value, field)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func csvInt(value, field string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ValidationError{Field: field, Reason: "must be an integer"}
+	}
+	return i, nil
+}
+
+// ExportFilter selects apartments for the export command. Zero values mean "no restriction".
+type ExportFilter struct {
+	City   City
+	Status ListingStatus
+	Owner  string
+}
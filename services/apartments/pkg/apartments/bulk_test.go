@@ -0,0 +1,323 @@
+package apartments
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestImportRowApartment(t *testing.T) {
+	row := func(ref, owner string, status ListingStatus) ImportRow {
+		return ImportRow{ExternalRef: ref, Owner: owner, Status: status, ApartmentDetails: validDetails()}
+	}
+	tests := []struct {
+		name      string
+		row       ImportRow
+		wantField string
+	}{
+		{name: "draft", row: row("A-1", "owner", "")},
+		{name: "published", row: row(" A-1 ", " owner ", StatusPublished)},
+		{name: "no reference", row: row(" ", "owner", ""), wantField: "externalRef"},
+		{name: "long reference", row: row(strings.Repeat("a", maxExternalRefLength+1), "owner", ""), wantField: "externalRef"},
+		{name: "no owner", row: row("A-1", "", ""), wantField: "owner"},
+		{name: "unknown status", row: row("A-1", "owner", "live"), wantField: "status"},
+		{name: "invalid details", row: ImportRow{ExternalRef: "A-1", Owner: "owner"}, wantField: "title"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := tt.row
+			apartment, err := row.Apartment()
+			assertValidationField(t, err, tt.wantField)
+			if err != nil {
+				return
+			}
+			if apartment.ExternalRef != "A-1" || apartment.Owner != "owner" || apartment.Status != tt.row.Status {
+				t.Errorf("got ref %q of %q in %q", apartment.ExternalRef, apartment.Owner, apartment.Status)
+			}
+			if apartment.Title != validDetails().Title || apartment.CityID != "lisbon" || apartment.Created.IsZero() {
+				t.Errorf("got %+v, want the details applied", apartment)
+			}
+		})
+	}
+}
+
+type readRow struct {
+	number    int
+	ref       string
+	wantField string
+	wantErr   bool
+}
+
+func TestReadImportRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []readRow
+		wantErr bool
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input:  "externalRef,title,id\nA-1,Flat,ignored\n\nA-2,Loft,\n",
+			want:   []readRow{{number: 2, ref: "A-1"}, {number: 3, ref: "A-2"}},
+		},
+		{
+			name:   "csv invalid values",
+			format: FormatCSV,
+			input: "externalRef,price,capacity,bedrooms,instantBook,lat,lng\n" +
+				"A-1,cheap,,,,,\nA-2,,2.5,,,,\nA-3,,,one,,,\nA-4,,,,yes,,\nA-5,,,,,north,\nA-6,,,,,,west\nA-7,80,2,1,true,38.7,-9.1\n",
+			want: []readRow{
+				{number: 2, wantField: "price"},
+				{number: 3, wantField: "capacity"},
+				{number: 4, wantField: "bedrooms"},
+				{number: 5, wantField: "instantBook"},
+				{number: 6, wantField: "lat"},
+				{number: 7, wantField: "lng"},
+				{number: 8, ref: "A-7"},
+			},
+		},
+		{
+			name:   "csv malformed row",
+			format: FormatCSV,
+			input:  "externalRef,owner\nA-1,own\"er\nA-2,owner\n",
+			want:   []readRow{{number: 2, wantErr: true}, {number: 3, ref: "A-2"}},
+		},
+		{name: "csv without header", format: FormatCSV, input: "", wantErr: true},
+		{
+			name:   "ndjson",
+			format: FormatNDJSON,
+			input:  "{\"externalRef\":\"A-1\"}\n\n{\"externalRef\":\"A-2\",\"price\":\"cheap\"}\n{\"externalRef\":\"A-3\"}\n",
+			want:   []readRow{{number: 1, ref: "A-1"}, {number: 3, wantErr: true}, {number: 4, ref: "A-3"}},
+		},
+		{name: "unknown format", format: "xml", input: "<apartments/>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []readRow
+			err := ReadImportRows(strings.NewReader(tt.input), tt.format, func(rowNumber int, row ImportRow, err error) error {
+				var validationErr *ValidationError
+				switch {
+				case errors.As(err, &validationErr):
+					got = append(got, readRow{number: rowNumber, wantField: validationErr.Field})
+				case err != nil:
+					got = append(got, readRow{number: rowNumber, wantErr: true})
+				default:
+					got = append(got, readRow{number: rowNumber, ref: row.ExternalRef})
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got rows %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadImportRowsValues(t *testing.T) {
+	lat, lng := 38.7, -9.1
+	want := ImportRow{
+		ExternalRef: "A-1",
+		Owner:       "owner",
+		Status:      StatusPublished,
+		ApartmentDetails: ApartmentDetails{
+			Title: "Flat", Description: "By the river", Address: "1 River street", City: "Lisbon", Price: 80.5,
+			Capacity: 4, Bedrooms: 2, Amenities: []string{"wifi", "kitchen"}, InstantBook: true, Latitude: &lat, Longitude: &lng,
+		},
+	}
+	inputs := map[string]string{
+		FormatCSV: strings.Join(ImportColumns, ",") + "\n" +
+			"A-1, owner,published,Flat,By the river,1 River street,Lisbon,80.5,4,2,wifi|kitchen,true,38.7,-9.1\n",
+		FormatNDJSON: `{"externalRef":"A-1","owner":"owner","status":"published","title":"Flat","description":"By the river",` +
+			`"address":"1 River street","city":"Lisbon","price":80.5,"capacity":4,"bedrooms":2,"amenities":["wifi","kitchen"],` +
+			`"instantBook":true,"lat":38.7,"lng":-9.1}` + "\n",
+	}
+	for format, input := range inputs {
+		t.Run(format, func(t *testing.T) {
+			var rows []ImportRow
+			err := ReadImportRows(strings.NewReader(input), format, func(_ int, row ImportRow, err error) error {
+				rows = append(rows, row)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 || !reflect.DeepEqual(rows[0], want) {
+				t.Errorf("got rows %+v, want %+v", rows, want)
+			}
+		})
+	}
+}
+
+// upserts keeps imported apartments by owner and external reference the way UpsertApartmentByExternalRef does.
+type upserts struct {
+	apartments map[string]Apartment
+	err        map[string]error
+}
+
+func (u *upserts) upsert(_ context.Context, apartment *Apartment) (bool, error) {
+	if err := u.err[apartment.ExternalRef]; err != nil {
+		return false, err
+	}
+	key := apartment.Owner + "/" + apartment.ExternalRef
+	existing, found := u.apartments[key]
+	saved := *apartment
+	if found {
+		saved.Created = existing.Created
+		if saved.Status == "" {
+			saved.Status = existing.Status
+		}
+	} else if saved.Status == "" {
+		saved.Status = StatusDraft
+	}
+	u.apartments[key] = saved
+	return !found, nil
+}
+
+func TestImportApartments(t *testing.T) {
+	input := "externalRef,owner,title,address,city,price,capacity\n" +
+		"A-1,,Flat by the river,1 River street,Lisbon,80,2\n" +
+		"A-2,other,Loft by the sea,2 Sea street,Lisbon,90,2\n" +
+		"A-3,,ab,3 River street,Lisbon,80,2\n" +
+		"A-1,,Flat by the river,1 River street,Lisbon,80,2\n" +
+		"A-4,,Flat by the park,4 Park street,Lisbon,70,2\n" +
+		"A-5,,Flat by the sea,5 Sea street,Lisbon,cheap,2\n"
+	tests := []struct {
+		name      string
+		upsert    bool
+		want      ImportSummary
+		wantRows  []int
+		wantSaved []string
+	}{
+		{
+			name:      "import",
+			upsert:    true,
+			want:      ImportSummary{Rows: 6, Created: 2, Failed: 4},
+			wantRows:  []int{4, 5, 6, 7},
+			wantSaved: []string{"other/A-2", "owner/A-1"},
+		},
+		{name: "dry run", want: ImportSummary{Rows: 6, Failed: 3}, wantRows: []int{4, 5, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &upserts{apartments: map[string]Apartment{}, err: map[string]error{"A-4": ErrDatabase}}
+			var upsert UpsertFunc
+			if tt.upsert {
+				upsert = store.upsert
+			}
+			var rows []int
+			summary, err := ImportApartments(context.Background(), strings.NewReader(input), FormatCSV, "owner", upsert,
+				func(err *RowError) { rows = append(rows, err.Row) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary != tt.want {
+				t.Errorf("got summary %+v, want %+v", summary, tt.want)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("got failed rows %v, want %v", rows, tt.wantRows)
+			}
+			var saved []string
+			for key := range store.apartments {
+				saved = append(saved, key)
+			}
+			sort.Strings(saved)
+			if !reflect.DeepEqual(saved, tt.wantSaved) {
+				t.Errorf("got saved %v, want %v", saved, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestImportApartmentsTwice(t *testing.T) {
+	input := "{\"externalRef\":\"A-1\",\"owner\":\"owner\",\"title\":\"Flat by the river\",\"address\":\"1 River street\"," +
+		"\"city\":\"Lisbon\",\"price\":80,\"capacity\":2}\n" +
+		"{\"externalRef\":\"A-2\",\"owner\":\"owner\",\"status\":\"published\",\"title\":\"Loft by the sea\"," +
+		"\"address\":\"2 Sea street\",\"city\":\"Lisbon\",\"price\":90,\"capacity\":2}\n"
+	store := &upserts{apartments: map[string]Apartment{}}
+	importOnce := func() ImportSummary {
+		summary, err := ImportApartments(context.Background(), strings.NewReader(input), FormatNDJSON, "", store.upsert,
+			func(err *RowError) { t.Error(err) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return summary
+	}
+
+	if got := importOnce(); got != (ImportSummary{Rows: 2, Created: 2}) {
+		t.Errorf("got first summary %+v, want 2 created", got)
+	}
+	first := make(map[string]Apartment, len(store.apartments))
+	for key, apartment := range store.apartments {
+		first[key] = apartment
+	}
+	if got := importOnce(); got != (ImportSummary{Rows: 2, Updated: 2}) {
+		t.Errorf("got second summary %+v, want 2 updated", got)
+	}
+	if !reflect.DeepEqual(store.apartments, first) {
+		t.Errorf("got %+v after importing again, want %+v", store.apartments, first)
+	}
+}
+
+func TestExternalRefUpsert(t *testing.T) {
+	field := func(d bson.D, key string) interface{} {
+		for _, e := range d {
+			if e.Key == key {
+				return e.Value
+			}
+		}
+		return nil
+	}
+	tests := []struct {
+		name             string
+		status           ListingStatus
+		wantSetStatus    interface{}
+		wantInsertStatus interface{}
+	}{
+		{name: "without status", wantInsertStatus: StatusDraft},
+		{name: "with status", status: StatusPublished, wantSetStatus: StatusPublished},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := ImportRow{ExternalRef: "A-1", Owner: "owner", Status: tt.status, ApartmentDetails: validDetails()}
+			apartment, err := row.Apartment()
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, update := externalRefUpsert(apartment)
+			reimported := *apartment
+			reimported.Created = apartment.Created.Add(time.Hour)
+			againFilter, againUpdate := externalRefUpsert(&reimported)
+
+			want := bson.D{{Key: "owner", Value: "owner"}, {Key: "externalRef", Value: "A-1"}}
+			if !reflect.DeepEqual(filter, want) || !reflect.DeepEqual(againFilter, want) {
+				t.Errorf("got filters %v and %v, want %v", filter, againFilter, want)
+			}
+			set, setOnInsert := field(update, "$set").(bson.D), field(update, "$setOnInsert").(bson.D)
+			if !reflect.DeepEqual(set, field(againUpdate, "$set")) {
+				t.Errorf("got $set %v and %v, want importing again to set the same fields", set, field(againUpdate, "$set"))
+			}
+			if field(set, "created") != nil || field(set, "photos") != nil {
+				t.Errorf("got $set %v, want creation date and photos only set on insert", set)
+			}
+			if field(setOnInsert, "created") != apartment.Created || field(setOnInsert, "photos") == nil {
+				t.Errorf("got $setOnInsert %v, want creation date and photos", setOnInsert)
+			}
+			if got := field(set, "status"); got != tt.wantSetStatus {
+				t.Errorf("got $set status %v, want %v", got, tt.wantSetStatus)
+			}
+			if got := field(setOnInsert, "status"); got != tt.wantInsertStatus {
+				t.Errorf("got $setOnInsert status %v, want %v", got, tt.wantInsertStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package apartments
+
+import (
+	"sort"
+	"strings"
+)
+
+const maxCitySuggestions = 10
+
+// CityInfo is an entry of the cities catalog. Apartments store the canonical Name and ID, aliases are
+// only used to recognise what users type.
+type CityInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Country  string `json:"country"`
+	TimeZone string `json:"timeZone"`
+	// Latitude and Longitude are the coordinates of the city center.
+	Latitude  float64  `json:"lat"`
+	Longitude float64  `json:"lng"`
+	Aliases   []string `json:"aliases,omitempty"`
+}
+
+// citiesCatalog lists the cities apartments can be offered in. Never change an ID, stored apartments refer to them.
+var citiesCatalog = []CityInfo{
+	{ID: "amsterdam", Name: "Amsterdam", Country: "NL", TimeZone: "Europe/Amsterdam",
+		Latitude: 52.3676, Longitude: 4.9041},
+	{ID: "barcelona", Name: "Barcelona", Country: "ES", TimeZone: "Europe/Madrid",
+		Latitude: 41.3874, Longitude: 2.1686},
+	{ID: "berlin", Name: "Berlin", Country: "DE", TimeZone: "Europe/Berlin",
+		Latitude: 52.52, Longitude: 13.405},
+	{ID: "cork", Name: "Cork", Country: "IE", TimeZone: "Europe/Dublin",
+		Latitude: 51.8985, Longitude: -8.4756, Aliases: []string{"Corcaigh"}},
+	{ID: "dublin", Name: "Dublin", Country: "IE", TimeZone: "Europe/Dublin",
+		Latitude: 53.3498, Longitude: -6.2603, Aliases: []string{"Baile Átha Cliath", "Dublin City"}},
+	{ID: "galway", Name: "Galway", Country: "IE", TimeZone: "Europe/Dublin",
+		Latitude: 53.2707, Longitude: -9.0568, Aliases: []string{"Gaillimh"}},
+	{ID: "hamburg", Name: "Hamburg", Country: "DE", TimeZone: "Europe/Berlin",
+		Latitude: 53.5511, Longitude: 9.9937},
+	{ID: "lisbon", Name: "Lisbon", Country: "PT", TimeZone: "Europe/Lisbon",
+		Latitude: 38.7223, Longitude: -9.1393, Aliases: []string{"Lisboa"}},
+	{ID: "london", Name: "London", Country: "GB", TimeZone: "Europe/London",
+		Latitude: 51.5072, Longitude: -0.1276, Aliases: []string{"Londres", "Greater London"}},
+	{ID: "madrid", Name: "Madrid", Country: "ES", TimeZone: "Europe/Madrid",
+		Latitude: 40.4168, Longitude: -3.7038},
+	{ID: "manchester", Name: "Manchester", Country: "GB", TimeZone: "Europe/London",
+		Latitude: 53.4808, Longitude: -2.2426},
+	{ID: "munich", Name: "Munich", Country: "DE", TimeZone: "Europe/Berlin",
+		Latitude: 48.1351, Longitude: 11.582, Aliases: []string{"München", "Muenchen", "Munchen"}},
+	{ID: "paris", Name: "Paris", Country: "FR", TimeZone: "Europe/Paris",
+		Latitude: 48.8566, Longitude: 2.3522},
+	{ID: "prague", Name: "Prague", Country: "CZ", TimeZone: "Europe/Prague",
+		Latitude: 50.0755, Longitude: 14.4378, Aliases: []string{"Praha", "Prag"}},
+	{ID: "rome", Name: "Rome", Country: "IT", TimeZone: "Europe/Rome",
+		Latitude: 41.9028, Longitude: 12.4964, Aliases: []string{"Roma", "Rom"}},
+	{ID: "vienna", Name: "Vienna", Country: "AT", TimeZone: "Europe/Vienna",
+		Latitude: 48.2082, Longitude: 16.3738, Aliases: []string{"Wien"}},
+}
+
+// Cities returns the whole catalog ordered by ID.
+func Cities() []CityInfo {
+	return append([]CityInfo(nil), citiesCatalog...)
+}
+
+// citiesByKey indexes the catalog by normalized ID, name and aliases.
+var citiesByKey = func() map[string]*CityInfo {
+	byKey := make(map[string]*CityInfo, len(citiesCatalog)*2)
+	for i := range citiesCatalog {
+		city := &citiesCatalog[i]
+		byKey[cityKey(city.ID)] = city
+		byKey[cityKey(city.Name)] = city
+		for _, alias := range city.Aliases {
+			byKey[cityKey(alias)] = city
+		}
+	}
+	return byKey
+}()
+
+func cityKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// LookupCity resolves a city ID, name or alias to the catalog entry, ignoring case and extra spaces.
+func LookupCity(name string) (*CityInfo, bool) {
+	city, ok := citiesByKey[cityKey(name)]
+	return city, ok
+}
+
+// canonicalCity returns the canonical name of a known city, or the city as is so that unknown
+// cities simply match nothing.
+func canonicalCity(city City) City {
+	if info, ok := LookupCity(string(city)); ok {
+		return City(info.Name)
+	}
+	return city
+}
+
+// SuggestCities returns up to limit cities whose name or one of the aliases starts with prefix.
+func SuggestCities(prefix string, limit int) []CityInfo {
+	if limit <= 0 || limit > maxCitySuggestions {
+		limit = maxCitySuggestions
+	}
+	prefix = cityKey(prefix)
+	suggestions := make([]CityInfo, 0, limit)
+	for _, city := range citiesCatalog {
+		if cityMatchesPrefix(city, prefix) {
+			suggestions = append(suggestions, city)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Name < suggestions[j].Name
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+func cityMatchesPrefix(city CityInfo, prefix string) bool {
+	if strings.HasPrefix(cityKey(city.Name), prefix) {
+		return true
+	}
+	for _, alias := range city.Aliases {
+		if strings.HasPrefix(cityKey(alias), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package apartments
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLookupCity(t *testing.T) {
+	tests := []struct {
+		name   string
+		wantID string
+	}{
+		{name: "Lisbon", wantID: "lisbon"},
+		{name: "lisbon", wantID: "lisbon"},
+		{name: "  LISBOA ", wantID: "lisbon"},
+		{name: "Baile  Átha Cliath", wantID: "dublin"},
+		{name: "München", wantID: "munich"},
+		{name: "munich", wantID: "munich"},
+		{name: "Atlantis"},
+		{name: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			city, ok := LookupCity(tt.name)
+			switch {
+			case tt.wantID == "" && ok:
+				t.Errorf("found %q, want no city", city.ID)
+			case tt.wantID != "" && (!ok || city.ID != tt.wantID):
+				t.Errorf("got %v, want %q", city, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCanonicalCity(t *testing.T) {
+	if got := canonicalCity(" praha "); got != "Prague" {
+		t.Errorf("got %q, want Prague", got)
+	}
+	if got := canonicalCity("Atlantis"); got != "Atlantis" {
+		t.Errorf("got %q, want the unknown city as is", got)
+	}
+}
+
+func TestSuggestCities(t *testing.T) {
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{prefix: "m", want: []string{"madrid", "manchester", "munich"}},
+		{prefix: "M", limit: 2, want: []string{"madrid", "manchester"}},
+		{prefix: "mü", want: []string{"munich"}},
+		{prefix: "lon", want: []string{"london"}},
+		{prefix: "londres", want: []string{"london"}},
+		{prefix: "gaill", want: []string{"galway"}},
+		{prefix: "x"},
+		{prefix: "", limit: 100, want: []string{
+			"amsterdam", "barcelona", "berlin", "cork", "dublin", "galway", "hamburg", "lisbon", "london", "madrid",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got := make([]string, 0, len(tt.want))
+			for _, city := range SuggestCities(tt.prefix, tt.limit) {
+				got = append(got, city.ID)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCitiesCatalog(t *testing.T) {
+	for _, city := range Cities() {
+		if _, err := time.LoadLocation(city.TimeZone); err != nil {
+			t.Errorf("%s: %v", city.ID, err)
+		}
+		if found, ok := LookupCity(city.ID); !ok || found.ID != city.ID {
+			t.Errorf("%s is not found by its id", city.ID)
+		}
+		if !validLatitude(city.Latitude) || !validLongitude(city.Longitude) {
+			t.Errorf("%s center %v, %v is out of range", city.ID, city.Latitude, city.Longitude)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package apartments
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// listCursor is the state behind the opaque cursor of the apartments listing. Listings ordered by _id
+// continue after the last returned apartment. Full-text listings are ordered by relevance, which has no
+// stable key to continue from, so their cursor keeps the offset of the next page instead.
+type listCursor struct {
+	AfterID primitive.ObjectID `json:"a,omitempty"`
+	Offset  int                `json:"o,omitempty"`
+}
+
+func encodeListCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(cursor string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, &ValidationError{Field: "cursor", Reason: "is malformed"}
+	}
+	if err = json.Unmarshal(data, &c); err != nil || c.Offset < 0 {
+		return c, &ValidationError{Field: "cursor", Reason: "is malformed"}
+	}
+	return c, nil
+}
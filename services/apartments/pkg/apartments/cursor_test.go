@@ -0,0 +1,85 @@
+package apartments
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor listCursor
+	}{
+		{name: "after id", cursor: listCursor{AfterID: primitive.NewObjectID()}},
+		{name: "offset", cursor: listCursor{Offset: 40}},
+		{name: "empty", cursor: listCursor{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeListCursor(encodeListCursor(tt.cursor))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.cursor {
+				t.Errorf("got %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeListCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not json", cursor: base64.RawURLEncoding.EncodeToString([]byte("offset=3"))},
+		{name: "wrong id", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"a":"123"}`))},
+		{name: "negative offset", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"o":-20}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeListCursor(tt.cursor)
+			assertValidationField(t, err, "cursor")
+		})
+	}
+}
+
+func TestListFilterCursor(t *testing.T) {
+	afterID := primitive.NewObjectID()
+	tests := []struct {
+		name        string
+		filter      ListFilter
+		wantField   string
+		wantAfterID primitive.ObjectID
+		wantOffset  int
+	}{
+		{
+			name:        "listing cursor",
+			filter:      ListFilter{Cursor: encodeListCursor(listCursor{AfterID: afterID}), Offset: 60},
+			wantAfterID: afterID,
+		},
+		{
+			name:       "search cursor",
+			filter:     ListFilter{Query: "river", Cursor: encodeListCursor(listCursor{Offset: 20})},
+			wantOffset: 20,
+		},
+		{
+			name:      "listing cursor in a search",
+			filter:    ListFilter{Query: "river", Cursor: encodeListCursor(listCursor{AfterID: afterID})},
+			wantField: "cursor",
+		},
+		{name: "invalid cursor", filter: ListFilter{Cursor: "%%%"}, wantField: "cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			assertValidationField(t, filter.Normalize(), tt.wantField)
+			if tt.wantField == "" && (filter.afterID != tt.wantAfterID || filter.Offset != tt.wantOffset) {
+				t.Errorf("got after %s at offset %d, want after %s at %d", filter.afterID.Hex(), filter.Offset, tt.wantAfterID.Hex(), tt.wantOffset)
+			}
+		})
+	}
+}
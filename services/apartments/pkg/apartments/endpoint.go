@@ -3,6 +3,8 @@ package apartments
 import (
 	"context"
 
+	"contracts/pkg/contracts"
+
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -11,9 +13,22 @@ type Errorer interface {
 }
 
 type getApartmentsRequest struct {
-	City   City `json:"city"`
-	Limit  int  `json:"limit"`
-	Offset int  `json:"offset"`
+	ViewerID  string   `json:"-"`
+	City      City     `json:"city"`
+	Query     string   `json:"q"`
+	Amenities []string `json:"amenities"`
+	Cursor    string   `json:"cursor"`
+	Limit     int      `json:"limit"`
+	Offset    int      `json:"offset"`
+}
+
+type listApartmentsResponse struct {
+	*ApartmentsPage
+	Err error `json:"error,omitempty"`
+}
+
+func (l listApartmentsResponse) Error() error {
+	return l.Err
 }
 
 type getApartmentsResponse struct {
@@ -28,30 +43,463 @@ func (g getApartmentsResponse) Error() error {
 func makeGetApartmentsEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(getApartmentsRequest)
-		apartments, err := s.GetApartments(ctx, req.City, req.Limit, req.Offset)
-		return getApartmentsResponse{
-			Apartments: apartments,
-			Err:        err,
+		page, err := s.GetApartments(ctx, ListFilter{
+			ViewerID:  req.ViewerID,
+			City:      req.City,
+			Query:     req.Query,
+			Amenities: req.Amenities,
+			Cursor:    req.Cursor,
+			Limit:     req.Limit,
+			Offset:    req.Offset,
+		})
+		return listApartmentsResponse{
+			ApartmentsPage: page,
+			Err:            err,
 		}, nil
 	}
 }
 
-type getApartmentByIDRequest struct {
-	ApartmentID string `json:"apartmentId"`
+func makeGetApartmentByIDEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(contracts.GetApartmentByIDRequest)
+		apartment, err := s.GetApartmentByID(ctx, req.UserID, req.ApartmentID)
+		response := contracts.GetApartmentByIDResponse{Err: newReplyError(err)}
+		if apartment != nil {
+			response.Apartment = toContractApartment(apartment)
+		}
+		return response, nil
+	}
 }
 
-type getApartmentByIDResponse struct {
+// legacyGetApartmentByIDResponse is the reply to legacyGetApartmentByIDSubject, as services which predate
+// the contracts read it: a missing apartment is an apartment without an ID, and err is only set for other errors.
+type legacyGetApartmentByIDResponse struct {
 	Apartment *Apartment `json:"apartment"`
-	Err       error      `json:"err,omitempty"`
+	Err       string     `json:"err,omitempty"`
 }
 
-func makeGetApartmentByIDEndpoint(s Service) endpoint.Endpoint {
+func makeLegacyGetApartmentByIDEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(getApartmentByIDRequest)
-		apartment, err := s.GetApartmentByID(ctx, req.ApartmentID)
-		return getApartmentByIDResponse{
-			Apartment: apartment,
-			Err:       err,
-		}, nil
+		req := request.(contracts.GetApartmentByIDRequest)
+		apartment, err := s.GetApartmentByID(ctx, req.UserID, req.ApartmentID)
+		if replyErr := newReplyError(err); replyErr != nil && replyErr.Code != contracts.CodeNotFound {
+			return legacyGetApartmentByIDResponse{Err: replyErr.Message}, nil
+		}
+		return legacyGetApartmentByIDResponse{Apartment: apartment}, nil
+	}
+}
+
+type createApartmentRequest struct {
+	UserClaim
+	ApartmentDetails
+}
+
+func (c *createApartmentRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type apartmentResponse struct {
+	Apartment *Apartment `json:"apartment,omitempty"`
+	Err       error      `json:"error,omitempty"`
+}
+
+func (a apartmentResponse) Error() error {
+	return a.Err
+}
+
+func makeCreateApartmentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*createApartmentRequest)
+		apartment, err := s.CreateApartment(ctx, req.UserClaim.ID, req.ApartmentDetails)
+		return apartmentResponse{Apartment: apartment, Err: err}, nil
+	}
+}
+
+type updateApartmentRequest struct {
+	UserClaim
+	ApartmentDetails
+	ApartmentID string `json:"-"`
+}
+
+func (c *updateApartmentRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+func makeUpdateApartmentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*updateApartmentRequest)
+		apartment, err := s.UpdateApartment(ctx, req.UserClaim.ID, req.ApartmentID, req.ApartmentDetails)
+		return apartmentResponse{Apartment: apartment, Err: err}, nil
+	}
+}
+
+type deleteApartmentRequest struct {
+	UserClaim
+	ApartmentID string
+}
+
+func (c *deleteApartmentRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type deleteApartmentResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (d deleteApartmentResponse) Error() error {
+	return d.Err
+}
+
+func makeDeleteApartmentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*deleteApartmentRequest)
+		err := s.DeleteApartment(ctx, req.UserClaim.ID, req.ApartmentID)
+		return deleteApartmentResponse{Err: err}, nil
+	}
+}
+
+type searchApartmentsRequest struct {
+	SearchFilter
+}
+
+type searchApartmentsResponse struct {
+	*SearchResult
+	Err error `json:"error,omitempty"`
+}
+
+func (s searchApartmentsResponse) Error() error {
+	return s.Err
+}
+
+func makeSearchApartmentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(searchApartmentsRequest)
+		result, err := s.SearchApartments(ctx, req.SearchFilter)
+		return searchApartmentsResponse{SearchResult: result, Err: err}, nil
+	}
+}
+
+type geoApartmentsResponse struct {
+	Apartments []ApartmentWithDistance `json:"apartments"`
+	Err        error                   `json:"error,omitempty"`
+}
+
+func (g geoApartmentsResponse) Error() error {
+	return g.Err
+}
+
+func makeGetApartmentsNearEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(NearQuery)
+		apartments, err := s.GetApartmentsNear(ctx, req)
+		return geoApartmentsResponse{Apartments: apartments, Err: err}, nil
+	}
+}
+
+func makeGetApartmentsWithinEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BoundingBox)
+		apartments, err := s.GetApartmentsWithin(ctx, req)
+		return geoApartmentsResponse{Apartments: apartments, Err: err}, nil
+	}
+}
+
+type addPhotoRequest struct {
+	UserClaim
+	ApartmentID string
+	Data        []byte
+}
+
+func (c *addPhotoRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type photoResponse struct {
+	Photo *Photo `json:"photo,omitempty"`
+	Err   error  `json:"error,omitempty"`
+}
+
+func (p photoResponse) Error() error {
+	return p.Err
+}
+
+func makeAddPhotoEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*addPhotoRequest)
+		photo, err := s.AddPhoto(ctx, req.UserClaim.ID, req.ApartmentID, req.Data)
+		return photoResponse{Photo: photo, Err: err}, nil
+	}
+}
+
+type photoRequest struct {
+	UserClaim
+	ApartmentID string
+	PhotoID     string
+}
+
+func (c *photoRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+func makeDeletePhotoEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*photoRequest)
+		apartment, err := s.DeletePhoto(ctx, req.UserClaim.ID, req.ApartmentID, req.PhotoID)
+		return apartmentResponse{Apartment: apartment, Err: err}, nil
+	}
+}
+
+func makeSetCoverPhotoEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*photoRequest)
+		apartment, err := s.SetCoverPhoto(ctx, req.UserClaim.ID, req.ApartmentID, req.PhotoID)
+		return apartmentResponse{Apartment: apartment, Err: err}, nil
+	}
+}
+
+type reorderPhotosRequest struct {
+	UserClaim
+	ApartmentID string   `json:"-"`
+	PhotoIDs    []string `json:"photoIds"`
+}
+
+func (c *reorderPhotosRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+func makeReorderPhotosEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*reorderPhotosRequest)
+		apartment, err := s.ReorderPhotos(ctx, req.UserClaim.ID, req.ApartmentID, req.PhotoIDs)
+		return apartmentResponse{Apartment: apartment, Err: err}, nil
+	}
+}
+
+type getAmenitiesRequest struct {
+	Language string
+}
+
+type getAmenitiesResponse struct {
+	Amenities []LocalizedAmenity `json:"amenities"`
+	Err       error              `json:"error,omitempty"`
+}
+
+func (g getAmenitiesResponse) Error() error {
+	return g.Err
+}
+
+func makeGetAmenitiesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getAmenitiesRequest)
+		amenities, err := s.GetAmenities(ctx, req.Language)
+		return getAmenitiesResponse{Amenities: amenities, Err: err}, nil
+	}
+}
+
+type getCitiesRequest struct {
+	Prefix string
+	Limit  int
+}
+
+type getCitiesResponse struct {
+	Cities []CityInfo `json:"cities"`
+	Err    error      `json:"error,omitempty"`
+}
+
+func (g getCitiesResponse) Error() error {
+	return g.Err
+}
+
+func makeGetCitiesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getCitiesRequest)
+		cities, err := s.GetCities(ctx, req.Prefix, req.Limit)
+		return getCitiesResponse{Cities: cities, Err: err}, nil
+	}
+}
+
+type changeStatusRequest struct {
+	UserClaim
+	ApartmentID string        `json:"-"`
+	Status      ListingStatus `json:"status"`
+}
+
+func (c *changeStatusRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+func makeChangeApartmentStatusEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*changeStatusRequest)
+		apartment, err := s.ChangeApartmentStatus(ctx, req.UserClaim.ID, req.IsAdmin(), req.ApartmentID, req.Status)
+		return apartmentResponse{Apartment: apartment, Err: err}, nil
+	}
+}
+
+type userApartmentsRequest struct {
+	UserClaim
+	Status ListingStatus
+	Limit  int
+	Offset int
+}
+
+func (c *userApartmentsRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+func makeGetOwnerApartmentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*userApartmentsRequest)
+		apartments, err := s.GetOwnerApartments(ctx, req.UserClaim.ID)
+		return getApartmentsResponse{Apartments: apartments, Err: err}, nil
+	}
+}
+
+func makeGetApartmentsByOwnerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(contracts.GetApartmentsByOwnerRequest)
+		apartments, err := s.GetOwnerApartments(ctx, req.OwnerID)
+		owned := make([]contracts.Apartment, 0, len(apartments))
+		for i := range apartments {
+			owned = append(owned, *toContractApartment(&apartments[i]))
+		}
+		return contracts.GetApartmentsByOwnerResponse{Apartments: owned, Err: newReplyError(err)}, nil
+	}
+}
+
+// toContractApartment keeps the fields other services rely on.
+func toContractApartment(a *Apartment) *contracts.Apartment {
+	return &contracts.Apartment{
+		ID:       a.ID.Hex(),
+		Title:    a.Title,
+		Address:  a.Address,
+		Owner:    a.Owner,
+		Status:   string(a.Status),
+		City:     a.City,
+		Price:    a.Price,
+		TimeZone: a.TimeZone,
+	}
+}
+
+func makeGetApartmentsForModerationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*userApartmentsRequest)
+		apartments, err := s.GetApartmentsForModeration(ctx, req.IsAdmin(), req.Status, req.Limit, req.Offset)
+		return getApartmentsResponse{Apartments: apartments, Err: err}, nil
+	}
+}
+
+type wishlistRequest struct {
+	UserClaim
+	WishlistID  string `json:"-"`
+	ApartmentID string `json:"-"`
+	Name        string `json:"name"`
+}
+
+func (c *wishlistRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type wishlistResponse struct {
+	Wishlist *Wishlist `json:"wishlist,omitempty"`
+	Err      error     `json:"error,omitempty"`
+}
+
+func (w wishlistResponse) Error() error {
+	return w.Err
+}
+
+type wishlistViewResponse struct {
+	Wishlist *WishlistView `json:"wishlist,omitempty"`
+	Err      error         `json:"error,omitempty"`
+}
+
+func (w wishlistViewResponse) Error() error {
+	return w.Err
+}
+
+type wishlistsResponse struct {
+	Wishlists []Wishlist `json:"wishlists"`
+	Err       error      `json:"error,omitempty"`
+}
+
+func (w wishlistsResponse) Error() error {
+	return w.Err
+}
+
+type deleteWishlistResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (d deleteWishlistResponse) Error() error {
+	return d.Err
+}
+
+func makeGetWishlistsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		wishlists, err := s.GetWishlists(ctx, req.UserClaim.ID)
+		return wishlistsResponse{Wishlists: wishlists, Err: err}, nil
+	}
+}
+
+func makeCreateWishlistEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		wishlist, err := s.CreateWishlist(ctx, req.UserClaim.ID, req.Name)
+		return wishlistResponse{Wishlist: wishlist, Err: err}, nil
+	}
+}
+
+func makeGetWishlistEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		wishlist, err := s.GetWishlist(ctx, req.UserClaim.ID, req.WishlistID)
+		return wishlistViewResponse{Wishlist: wishlist, Err: err}, nil
+	}
+}
+
+type getSharedWishlistRequest struct {
+	Token string
+}
+
+func makeGetSharedWishlistEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getSharedWishlistRequest)
+		wishlist, err := s.GetSharedWishlist(ctx, req.Token)
+		return wishlistViewResponse{Wishlist: wishlist, Err: err}, nil
+	}
+}
+
+func makeDeleteWishlistEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		err := s.DeleteWishlist(ctx, req.UserClaim.ID, req.WishlistID)
+		return deleteWishlistResponse{Err: err}, nil
+	}
+}
+
+func makeAddToWishlistEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		wishlist, err := s.AddToWishlist(ctx, req.UserClaim.ID, req.WishlistID, req.ApartmentID)
+		return wishlistResponse{Wishlist: wishlist, Err: err}, nil
+	}
+}
+
+func makeRemoveFromWishlistEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		wishlist, err := s.RemoveFromWishlist(ctx, req.UserClaim.ID, req.WishlistID, req.ApartmentID)
+		return wishlistResponse{Wishlist: wishlist, Err: err}, nil
+	}
+}
+
+// makeShareWishlistEndpoint shares the wishlist, or stops sharing it when shared is false.
+func makeShareWishlistEndpoint(s Service, shared bool) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*wishlistRequest)
+		wishlist, err := s.ShareWishlist(ctx, req.UserClaim.ID, req.WishlistID, shared)
+		return wishlistResponse{Wishlist: wishlist, Err: err}, nil
 	}
 }
@@ -2,35 +2,69 @@ package apartments
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"contracts/pkg/contracts"
+	"telemetry/pkg/telemetry"
+
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/metrics"
 )
 
-type InstrumentingService struct {
-	requestCount   metrics.Counter
-	requestLatency metrics.Histogram
-	Service
-}
+// Transports label the metrics of requests with the way they were received.
+const (
+	TransportHTTP = "http"
+	TransportNATS = "nats"
+)
 
-func NewInstrumentingService(requestCount metrics.Counter, requestLatency metrics.Histogram, service Service) *InstrumentingService {
-	return &InstrumentingService{requestCount: requestCount, requestLatency: requestLatency, Service: service}
+// EndpointMetrics are the metrics of the requests served by endpoints, their count and their duration in
+// seconds. The zero EndpointMetrics records nothing.
+type EndpointMetrics struct {
+	Requests metrics.Counter
+	Duration metrics.Histogram
 }
 
-func (i *InstrumentingService) GetApartments(ctx context.Context, city City, limit, offset int) ([]Apartment, error) {
-	defer func(begin time.Time) {
-		i.requestCount.With("method", "GetApartments").Add(1)
-		i.requestLatency.With("method", "GetApartments").Observe(time.Since(begin).Seconds())
-	}(time.Now())
+// InstrumentingMiddleware records the requests for method received over transport, labelled by method,
+// transport and the class of their error, see telemetry.ErrorClass.
+func InstrumentingMiddleware(m EndpointMetrics, method, transport string) endpoint.Middleware {
+	if m.Requests == nil || m.Duration == nil {
+		return func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+	}
+	requests := m.Requests.With("method", method, "transport", transport)
+	duration := m.Duration.With("method", method, "transport", transport)
 
-	return i.Service.GetApartments(ctx, city, limit, offset)
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				class := errorClass(responseError(response, err))
+				requests.With("error", class).Add(1)
+				duration.With("error", class).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
 }
 
-func (i *InstrumentingService) GetApartmentByID(ctx context.Context, apartmentID string) (a *Apartment, err error) {
-	defer func(begin time.Time) {
-		i.requestCount.With("method", "GetApartmentByID").Add(1)
-		i.requestLatency.With("method", "GetApartmentByID").Observe(time.Since(begin).Seconds())
-	}(time.Now())
+// responseError is the error of the endpoint or else the one its response carries.
+func responseError(response interface{}, err error) error {
+	if err == nil {
+		if errorer, ok := response.(Errorer); ok {
+			return errorer.Error()
+		}
+	}
+	return err
+}
 
-	return i.Service.GetApartmentByID(ctx, apartmentID)
+// errorClass classifies errors replied over NATS by their code and the others by their HTTP status.
+func errorClass(err error) string {
+	var replyErr *contracts.ReplyError
+	switch {
+	case err == nil:
+		return telemetry.ErrorClassNone
+	case errors.As(err, &replyErr):
+		return string(replyErr.Code)
+	}
+	return telemetry.ErrorClass(statusOf(err))
 }
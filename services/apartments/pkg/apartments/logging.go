@@ -16,17 +16,18 @@ func NewLoggingService(logger *zap.Logger, service Service) Service {
 	return &loggingService{logger: logger, Service: service}
 }
 
-func (s *loggingService) GetApartments(ctx context.Context, city City, limit, offset int) (a []Apartment, err error) {
+func (s *loggingService) GetApartments(ctx context.Context, filter ListFilter) (p *ApartmentsPage, err error) {
 	defer func(begin time.Time) {
 		s.logger.Debug("calling GetApartments",
 			zap.Duration("took", time.Since(begin)),
+			zap.Any("filter", filter),
 			zap.Error(err),
 		)
 	}(time.Now())
-	return s.Service.GetApartments(ctx, city, limit, offset)
+	return s.Service.GetApartments(ctx, filter)
 }
 
-func (s *loggingService) GetApartmentByID(ctx context.Context, apartmentID string) (a *Apartment, err error) {
+func (s *loggingService) GetApartmentByID(ctx context.Context, viewerID, apartmentID string) (a *Apartment, err error) {
 	defer func(begin time.Time) {
 		s.logger.Debug("calling GetApartmentByID",
 			zap.Duration("took", time.Since(begin)),
@@ -35,5 +36,287 @@ func (s *loggingService) GetApartmentByID(ctx context.Context, apartmentID strin
 			zap.Error(err),
 		)
 	}(time.Now())
-	return s.Service.GetApartmentByID(ctx, apartmentID)
+	return s.Service.GetApartmentByID(ctx, viewerID, apartmentID)
+}
+
+func (s *loggingService) CreateApartment(ctx context.Context, ownerID string, details ApartmentDetails) (a *Apartment, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling CreateApartment",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.CreateApartment(ctx, ownerID, details)
+}
+
+func (s *loggingService) UpdateApartment(ctx context.Context, ownerID, apartmentID string, details ApartmentDetails) (a *Apartment, err error) { //nolint:lll
+	defer func(begin time.Time) {
+		s.logger.Debug("calling UpdateApartment",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.String("apartmentID", apartmentID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.UpdateApartment(ctx, ownerID, apartmentID, details)
+}
+
+func (s *loggingService) DeleteApartment(ctx context.Context, ownerID, apartmentID string) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling DeleteApartment",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.String("apartmentID", apartmentID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.DeleteApartment(ctx, ownerID, apartmentID)
+}
+
+func (s *loggingService) SearchApartments(ctx context.Context, filter SearchFilter) (result *SearchResult, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling SearchApartments",
+			zap.Duration("took", time.Since(begin)),
+			zap.Any("filter", filter),
+			zap.Bool("is result found", result != nil),
+			zap.Bool("is availability degraded", result != nil && result.AvailabilityDegraded),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.SearchApartments(ctx, filter)
+}
+
+func (s *loggingService) GetApartmentsNear(ctx context.Context, query NearQuery) (a []ApartmentWithDistance, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetApartmentsNear",
+			zap.Duration("took", time.Since(begin)),
+			zap.Any("query", query),
+			zap.Int("returned apartments", len(a)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetApartmentsNear(ctx, query)
+}
+
+func (s *loggingService) GetApartmentsWithin(ctx context.Context, box BoundingBox) (a []ApartmentWithDistance, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetApartmentsWithin",
+			zap.Duration("took", time.Since(begin)),
+			zap.Any("box", box),
+			zap.Int("returned apartments", len(a)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetApartmentsWithin(ctx, box)
+}
+
+func (s *loggingService) AddPhoto(ctx context.Context, ownerID, apartmentID string, data []byte) (p *Photo, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling AddPhoto",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.String("apartmentID", apartmentID),
+			zap.Int("size", len(data)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.AddPhoto(ctx, ownerID, apartmentID, data)
+}
+
+func (s *loggingService) DeletePhoto(ctx context.Context, ownerID, apartmentID, photoID string) (a *Apartment, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling DeletePhoto",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.String("apartmentID", apartmentID),
+			zap.String("photoID", photoID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.DeletePhoto(ctx, ownerID, apartmentID, photoID)
+}
+
+func (s *loggingService) ReorderPhotos(ctx context.Context, ownerID, apartmentID string, photoIDs []string) (a *Apartment, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling ReorderPhotos",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.String("apartmentID", apartmentID),
+			zap.Strings("photoIDs", photoIDs),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.ReorderPhotos(ctx, ownerID, apartmentID, photoIDs)
+}
+
+func (s *loggingService) SetCoverPhoto(ctx context.Context, ownerID, apartmentID, photoID string) (a *Apartment, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling SetCoverPhoto",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.String("apartmentID", apartmentID),
+			zap.String("photoID", photoID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.SetCoverPhoto(ctx, ownerID, apartmentID, photoID)
+}
+
+func (s *loggingService) GetAmenities(ctx context.Context, language string) (a []LocalizedAmenity, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetAmenities",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("language", language),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetAmenities(ctx, language)
+}
+
+func (s *loggingService) GetCities(ctx context.Context, prefix string, limit int) (c []CityInfo, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetCities",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("prefix", prefix),
+			zap.Int("limit", limit),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetCities(ctx, prefix, limit)
+}
+
+func (s *loggingService) GetOwnerApartments(ctx context.Context, ownerID string) (a []Apartment, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetOwnerApartments",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("owner", ownerID),
+			zap.Int("returned apartments", len(a)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetOwnerApartments(ctx, ownerID)
+}
+
+func (s *loggingService) GetApartmentsForModeration(ctx context.Context, isAdmin bool, status ListingStatus, limit, offset int) (a []Apartment, err error) { //nolint:lll
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetApartmentsForModeration",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("status", string(status)),
+			zap.Int("returned apartments", len(a)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetApartmentsForModeration(ctx, isAdmin, status, limit, offset)
+}
+
+func (s *loggingService) ChangeApartmentStatus(ctx context.Context, actorID string, isAdmin bool, apartmentID string, status ListingStatus) (a *Apartment, err error) { //nolint:lll
+	defer func(begin time.Time) {
+		s.logger.Debug("calling ChangeApartmentStatus",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("actor", actorID),
+			zap.Bool("is admin", isAdmin),
+			zap.String("apartmentID", apartmentID),
+			zap.String("status", string(status)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.ChangeApartmentStatus(ctx, actorID, isAdmin, apartmentID, status)
+}
+
+func (s *loggingService) GetWishlists(ctx context.Context, userID string) (w []Wishlist, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetWishlists",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.Int("returned wishlists", len(w)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetWishlists(ctx, userID)
+}
+
+func (s *loggingService) CreateWishlist(ctx context.Context, userID, name string) (w *Wishlist, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling CreateWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.CreateWishlist(ctx, userID, name)
+}
+
+func (s *loggingService) GetWishlist(ctx context.Context, userID, wishlistID string) (w *WishlistView, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.String("wishlistID", wishlistID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetWishlist(ctx, userID, wishlistID)
+}
+
+func (s *loggingService) GetSharedWishlist(ctx context.Context, token string) (w *WishlistView, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetSharedWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.Bool("is wishlist found", w != nil),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetSharedWishlist(ctx, token)
+}
+
+func (s *loggingService) DeleteWishlist(ctx context.Context, userID, wishlistID string) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling DeleteWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.String("wishlistID", wishlistID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.DeleteWishlist(ctx, userID, wishlistID)
+}
+
+func (s *loggingService) AddToWishlist(ctx context.Context, userID, wishlistID, apartmentID string) (w *Wishlist, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling AddToWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.String("wishlistID", wishlistID),
+			zap.String("apartmentID", apartmentID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.AddToWishlist(ctx, userID, wishlistID, apartmentID)
+}
+
+func (s *loggingService) RemoveFromWishlist(ctx context.Context, userID, wishlistID, apartmentID string) (w *Wishlist, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling RemoveFromWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.String("wishlistID", wishlistID),
+			zap.String("apartmentID", apartmentID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.RemoveFromWishlist(ctx, userID, wishlistID, apartmentID)
+}
+
+func (s *loggingService) ShareWishlist(ctx context.Context, userID, wishlistID string, shared bool) (w *Wishlist, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling ShareWishlist",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("user", userID),
+			zap.String("wishlistID", wishlistID),
+			zap.Bool("shared", shared),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.ShareWishlist(ctx, userID, wishlistID, shared)
 }
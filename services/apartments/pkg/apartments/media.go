@@ -0,0 +1,226 @@
+package apartments
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png" // registers PNG decoder for uploaded photos
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/image/draw"
+)
+
+const (
+	MaxPhotoSize          = 10 << 20
+	maxPhotoPixels        = 50000000
+	maxPhotosPerApartment = 30
+	photoJPEGQuality      = 85
+)
+
+const OriginalPhotoVariant = "original"
+
+// photoVariants maps resized variant names to their maximal width. Variants are never upscaled.
+var photoVariants = map[string]int{
+	"large":     1600,
+	"medium":    800,
+	"thumbnail": 240,
+}
+
+var allowedPhotoTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+var ErrPhotoTooLarge = errors.New("photo is too large")
+var ErrUnsupportedPhotoType = errors.New("photo must be a JPEG or PNG image")
+var ErrPhotoNotFound = errors.New("photo not found")
+var ErrTooManyPhotos = errors.New("apartment has too many photos")
+
+type Photo struct {
+	ID       string            `json:"id"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Variants map[string]string `json:"variants"`
+	Keys     []string          `json:"-"`
+	Uploaded time.Time         `json:"uploaded"`
+}
+
+type photoBlob struct {
+	variant   string
+	extension string
+	data      []byte
+}
+
+// AddPhoto validates an uploaded image, stores it with its resized variants and appends it to the apartment photos.
+// The first photo of an apartment becomes its cover.
+func (s *service) AddPhoto(ctx context.Context, ownerID, apartmentID string, data []byte) (*Photo, error) {
+	apartment, err := s.getOwnedApartment(ctx, ownerID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(apartment.Photos) >= maxPhotosPerApartment {
+		return nil, ErrTooManyPhotos
+	}
+	if len(data) > MaxPhotoSize {
+		return nil, ErrPhotoTooLarge
+	}
+	extension, ok := allowedPhotoTypes[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrUnsupportedPhotoType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedPhotoType
+	}
+	if config.Width*config.Height > maxPhotoPixels {
+		return nil, ErrPhotoTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedPhotoType
+	}
+
+	photo := &Photo{
+		ID:       primitive.NewObjectID().Hex(),
+		Width:    config.Width,
+		Height:   config.Height,
+		Variants: make(map[string]string, len(photoVariants)+1),
+		Uploaded: time.Now(),
+	}
+	blobs := []photoBlob{{variant: OriginalPhotoVariant, extension: extension, data: data}}
+	for name, width := range photoVariants {
+		resized, resizeErr := resizePhoto(img, width)
+		if resizeErr != nil {
+			return nil, resizeErr
+		}
+		blobs = append(blobs, photoBlob{variant: name, extension: "jpg", data: resized})
+	}
+	for _, blob := range blobs {
+		key := "apartments/" + apartmentID + "/" + photo.ID + "/" + blob.variant + "." + blob.extension
+		if err = s.blobs.Put(ctx, key, bytes.NewReader(blob.data)); err != nil {
+			s.deleteBlobs(ctx, photo.Keys)
+			return nil, err
+		}
+		photo.Keys = append(photo.Keys, key)
+		photo.Variants[blob.variant] = s.blobs.URL(key)
+	}
+
+	if err = s.ar.AddPhoto(ctx, apartmentID, photo, apartment.CoverPhotoID == "", maxPhotosPerApartment); err != nil {
+		s.deleteBlobs(ctx, photo.Keys)
+		return nil, err
+	}
+	return photo, nil
+}
+
+func (s *service) DeletePhoto(ctx context.Context, ownerID, apartmentID, photoID string) (*Apartment, error) {
+	apartment, err := s.getOwnedApartment(ctx, ownerID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	photos := make([]Photo, 0, len(apartment.Photos))
+	var deleted *Photo
+	for i := range apartment.Photos {
+		if apartment.Photos[i].ID == photoID {
+			deleted = &apartment.Photos[i]
+			continue
+		}
+		photos = append(photos, apartment.Photos[i])
+	}
+	if deleted == nil {
+		return nil, ErrPhotoNotFound
+	}
+
+	apartment.Photos = photos
+	if apartment.CoverPhotoID == photoID {
+		apartment.CoverPhotoID = ""
+		if len(photos) > 0 {
+			apartment.CoverPhotoID = photos[0].ID
+		}
+	}
+	if err = s.ar.UpdatePhotos(ctx, apartmentID, apartment.Photos, apartment.CoverPhotoID); err != nil {
+		return nil, err
+	}
+	s.deleteBlobs(ctx, deleted.Keys)
+	return apartment, nil
+}
+
+// ReorderPhotos sets the order of apartment photos, photoIDs must list every photo of the apartment exactly once.
+func (s *service) ReorderPhotos(ctx context.Context, ownerID, apartmentID string, photoIDs []string) (*Apartment, error) {
+	apartment, err := s.getOwnedApartment(ctx, ownerID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(photoIDs) != len(apartment.Photos) {
+		return nil, &ValidationError{Field: "photoIds", Reason: "must list every photo of the apartment exactly once"}
+	}
+	byID := make(map[string]Photo, len(apartment.Photos))
+	for _, photo := range apartment.Photos {
+		byID[photo.ID] = photo
+	}
+	photos := make([]Photo, 0, len(photoIDs))
+	for _, id := range photoIDs {
+		photo, ok := byID[id]
+		if !ok {
+			return nil, &ValidationError{Field: "photoIds", Reason: "must list every photo of the apartment exactly once"}
+		}
+		delete(byID, id)
+		photos = append(photos, photo)
+	}
+
+	apartment.Photos = photos
+	if err = s.ar.UpdatePhotos(ctx, apartmentID, apartment.Photos, apartment.CoverPhotoID); err != nil {
+		return nil, err
+	}
+	return apartment, nil
+}
+
+func (s *service) SetCoverPhoto(ctx context.Context, ownerID, apartmentID, photoID string) (*Apartment, error) {
+	apartment, err := s.getOwnedApartment(ctx, ownerID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, photo := range apartment.Photos {
+		found = found || photo.ID == photoID
+	}
+	if !found {
+		return nil, ErrPhotoNotFound
+	}
+
+	apartment.CoverPhotoID = photoID
+	if err = s.ar.UpdatePhotos(ctx, apartmentID, apartment.Photos, apartment.CoverPhotoID); err != nil {
+		return nil, err
+	}
+	return apartment, nil
+}
+
+// deleteBlobs removes stored media on a best effort basis, a leftover file is not worth failing a request.
+func (s *service) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		_ = s.blobs.Delete(ctx, key)
+	}
+}
+
+func resizePhoto(src image.Image, maxWidth int) ([]byte, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: photoJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,216 @@
+package apartments
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryBlobs keeps blobs in memory.
+type memoryBlobs map[string][]byte
+
+func (b memoryBlobs) Put(_ context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	b[key] = data
+	return err
+}
+
+func (b memoryBlobs) Delete(_ context.Context, key string) error {
+	delete(b, key)
+	return nil
+}
+
+func (b memoryBlobs) URL(key string) string {
+	return "/media/" + key
+}
+
+func encodeJPEG(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, nil) }
+
+func encodeGIF(w io.Writer, img image.Image) error { return gif.Encode(w, img, nil) }
+
+func encodedImage(t *testing.T, encode func(io.Writer, image.Image) error, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// hugeJPEG is a small JPEG whose header claims width by height pixels, which only decoding would find out.
+func hugeJPEG(t *testing.T, width, height uint16) []byte {
+	t.Helper()
+	data := encodedImage(t, encodeJPEG, 8, 8)
+	sof := bytes.Index(data, []byte{0xff, 0xc0})
+	if sof < 0 {
+		t.Fatal("no start of frame in the JPEG")
+	}
+	binary.BigEndian.PutUint16(data[sof+5:], height)
+	binary.BigEndian.PutUint16(data[sof+7:], width)
+	return data
+}
+
+// photoApartment is an apartment of owner with the given number of photos, the first one being the cover.
+func photoApartment(photos int) (*memoryRepository, string) {
+	apartment := Apartment{ID: primitive.NewObjectID(), Owner: "owner"}
+	for i := 0; i < photos; i++ {
+		apartment.Photos = append(apartment.Photos, Photo{ID: string(rune('a' + i%26)), Keys: []string{"key"}})
+	}
+	if photos > 0 {
+		apartment.CoverPhotoID = apartment.Photos[0].ID
+	}
+	id := apartment.ID.Hex()
+	return &memoryRepository{apartments: map[string]Apartment{id: apartment}}, id
+}
+
+func TestAddPhotoChecks(t *testing.T) {
+	jpegPhoto := encodedImage(t, encodeJPEG, 100, 50)
+	tests := []struct {
+		name    string
+		data    []byte
+		photos  int
+		wantErr error
+	}{
+		{name: "png", data: encodedImage(t, png.Encode, 200, 100)},
+		{name: "jpeg", data: jpegPhoto},
+		{name: "gif", data: encodedImage(t, encodeGIF, 10, 10), wantErr: ErrUnsupportedPhotoType},
+		{name: "text", data: []byte("not a photo"), wantErr: ErrUnsupportedPhotoType},
+		{name: "truncated jpeg", data: jpegPhoto[:len(jpegPhoto)/2], wantErr: ErrUnsupportedPhotoType},
+		{name: "too many pixels", data: hugeJPEG(t, 10000, 10000), wantErr: ErrPhotoTooLarge},
+		{name: "too many bytes", data: append(jpegPhoto, make([]byte, MaxPhotoSize)...), wantErr: ErrPhotoTooLarge},
+		{name: "too many photos", data: jpegPhoto, photos: maxPhotosPerApartment, wantErr: ErrTooManyPhotos},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blobs := memoryBlobs{}
+			repository, id := photoApartment(tt.photos)
+			_, err := NewService(repository, nil, blobs, nil).AddPhoto(context.Background(), "owner", id, tt.data)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && len(blobs) > 0 {
+				t.Errorf("%d blobs stored for a rejected photo", len(blobs))
+			}
+		})
+	}
+}
+
+func TestAddPhotoVariants(t *testing.T) {
+	blobs := memoryBlobs{}
+	repository, id := photoApartment(0)
+	s := NewService(repository, nil, blobs, nil)
+	photo, err := s.AddPhoto(context.Background(), "owner", id, encodedImage(t, png.Encode, 2000, 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apartment := repository.apartments[id]; apartment.CoverPhotoID != photo.ID || len(apartment.Photos) != 1 {
+		t.Errorf("got cover %q and %d photos, want the first photo as the cover", apartment.CoverPhotoID, len(apartment.Photos))
+	}
+	wantWidths := map[string]int{OriginalPhotoVariant: 2000, "large": 1600, "medium": 800, "thumbnail": 240}
+	if len(photo.Keys) != len(wantWidths) || len(blobs) != len(wantWidths) {
+		t.Fatalf("got keys %v and %d blobs, want %d", photo.Keys, len(blobs), len(wantWidths))
+	}
+	for _, key := range photo.Keys {
+		config, _, err := image.DecodeConfig(bytes.NewReader(blobs[key]))
+		if err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		variant := strings.TrimSuffix(path.Base(key), path.Ext(key))
+		if config.Width != wantWidths[variant] || config.Height != wantWidths[variant]/2 {
+			t.Errorf("%s is %dx%d, want %d wide with the same aspect", key, config.Width, config.Height, wantWidths[variant])
+		}
+		if photo.Variants[variant] != "/media/"+key {
+			t.Errorf("%s variant URL %q", variant, photo.Variants[variant])
+		}
+	}
+
+	// The limit is checked again by the repository, a concurrent upload may have reached it since.
+	for _, repositoryErr := range []error{ErrDatabase, ErrTooManyPhotos} {
+		repository.photoErr = repositoryErr
+		if _, err = s.AddPhoto(context.Background(), "owner", id, encodedImage(t, png.Encode, 10, 10)); err != repositoryErr {
+			t.Fatalf("got error %v, want %v", err, repositoryErr)
+		}
+		if len(blobs) != len(wantWidths) {
+			t.Errorf("blobs of the photo the repository refused with %v are left, %d blobs", repositoryErr, len(blobs))
+		}
+	}
+}
+
+func TestDeletePhoto(t *testing.T) {
+	tests := []struct {
+		photoID    string
+		wantErr    error
+		wantPhotos []string
+		wantCover  string
+	}{
+		{photoID: "b", wantPhotos: []string{"a", "c"}, wantCover: "a"},
+		{photoID: "a", wantPhotos: []string{"b", "c"}, wantCover: "b"},
+		{photoID: "z", wantErr: ErrPhotoNotFound, wantPhotos: []string{"a", "b", "c"}, wantCover: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.photoID, func(t *testing.T) {
+			repository, id := photoApartment(3)
+			blobs := memoryBlobs{"key": nil}
+			_, err := NewService(repository, nil, blobs, nil).DeletePhoto(context.Background(), "owner", id, tt.photoID)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			apartment := repository.apartments[id]
+			if got := photoIDs(apartment.Photos); !reflect.DeepEqual(got, tt.wantPhotos) || apartment.CoverPhotoID != tt.wantCover {
+				t.Errorf("got photos %v with cover %q, want %v with %q", got, apartment.CoverPhotoID, tt.wantPhotos, tt.wantCover)
+			}
+			if deleted := len(blobs) == 0; deleted != (err == nil) {
+				t.Errorf("blobs deleted %v", deleted)
+			}
+		})
+	}
+}
+
+func TestReorderPhotos(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     []string
+		wantField string
+	}{
+		{name: "reversed", order: []string{"c", "b", "a"}},
+		{name: "missing photo", order: []string{"c", "b"}, wantField: "photoIds"},
+		{name: "repeated photo", order: []string{"c", "c", "a"}, wantField: "photoIds"},
+		{name: "unknown photo", order: []string{"c", "b", "z"}, wantField: "photoIds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, id := photoApartment(3)
+			_, err := NewService(repository, nil, nil, nil).ReorderPhotos(context.Background(), "owner", id, tt.order)
+			assertValidationField(t, err, tt.wantField)
+			want := tt.order
+			if err != nil {
+				want = []string{"a", "b", "c"}
+			}
+			if got := photoIDs(repository.apartments[id].Photos); !reflect.DeepEqual(got, want) {
+				t.Errorf("got photos %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func photoIDs(photos []Photo) []string {
+	ids := make([]string, 0, len(photos))
+	for _, photo := range photos {
+		ids = append(ids, photo.ID)
+	}
+	return ids
+}
@@ -0,0 +1,26 @@
+package apartments
+
+import (
+	"errors"
+
+	"contracts/pkg/contracts"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newReplyError wraps err into the envelope sent to other services, nil stays nil.
+func newReplyError(err error) *contracts.ReplyError {
+	var validationErr *ValidationError
+	switch {
+	case err == nil:
+		return nil
+	case err == ErrApartmentNotFound, errors.Is(err, mongo.ErrNoDocuments):
+		return &contracts.ReplyError{Code: contracts.CodeNotFound, Message: ErrApartmentNotFound.Error()}
+	case err == ErrWrongIDFormat, errors.As(err, &validationErr):
+		return &contracts.ReplyError{Code: contracts.CodeInvalidArgument, Message: err.Error()}
+	case err == ErrDatabase:
+		return &contracts.ReplyError{Code: contracts.CodeUnavailable, Message: err.Error(), Retryable: true}
+	default:
+		return &contracts.ReplyError{Code: contracts.CodeInternal, Message: err.Error()}
+	}
+}
@@ -0,0 +1,62 @@
+package apartments
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"contracts/pkg/contracts"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewReplyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *contracts.ReplyError
+	}{
+		{name: "no error", err: nil, want: nil},
+		{
+			name: "not found",
+			err:  ErrApartmentNotFound,
+			want: &contracts.ReplyError{Code: contracts.CodeNotFound, Message: ErrApartmentNotFound.Error()},
+		},
+		{
+			name: "no documents",
+			err:  fmt.Errorf("find apartment: %w", mongo.ErrNoDocuments),
+			want: &contracts.ReplyError{Code: contracts.CodeNotFound, Message: ErrApartmentNotFound.Error()},
+		},
+		{
+			name: "wrong id",
+			err:  ErrWrongIDFormat,
+			want: &contracts.ReplyError{Code: contracts.CodeInvalidArgument, Message: ErrWrongIDFormat.Error()},
+		},
+		{
+			name: "validation",
+			err:  &ValidationError{Field: "price", Reason: "must be positive"},
+			want: &contracts.ReplyError{
+				Code:    contracts.CodeInvalidArgument,
+				Message: (&ValidationError{Field: "price", Reason: "must be positive"}).Error(),
+			},
+		},
+		{
+			name: "database",
+			err:  ErrDatabase,
+			want: &contracts.ReplyError{Code: contracts.CodeUnavailable, Message: ErrDatabase.Error(), Retryable: true},
+		},
+		{
+			name: "other",
+			err:  errors.New("boom"),
+			want: &contracts.ReplyError{Code: contracts.CodeInternal, Message: "boom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newReplyError(tt.err)
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package apartments
 
 import (
 	"context"
+	"strconv"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,34 +22,523 @@ func NewRepository(db *mongo.Database) *MongoRepositoryApartments {
 	return &MongoRepositoryApartments{db: db}
 }
 
-func (r *MongoRepositoryApartments) GetApartmentsByCity(ctx context.Context, city City, limit, offset int) ([]Apartment, error) {
-	if limit > maxApartmentLimit {
+// GetApartmentsByCity returns a page of published apartments ordered by _id, or by relevance for full-text
+// queries. One extra apartment is fetched to tell whether there is a next page.
+func (r *MongoRepositoryApartments) GetApartmentsByCity(ctx context.Context, filter ListFilter) (*ApartmentsPage, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > maxApartmentLimit {
 		limit = maxApartmentLimit
 	}
-	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
-	cursor, err := r.db.Collection(apartmentCollectionName).Find(ctx, bson.D{{"city", city}}, opts)
+	query := bson.D{{Key: "status", Value: StatusPublished}}
+	if filter.City != "" {
+		query = append(query, bson.E{Key: "city", Value: filter.City})
+	}
+	if len(filter.Amenities) > 0 {
+		query = append(query, bson.E{Key: "amenities", Value: bson.D{{Key: "$all", Value: filter.Amenities}}})
+	}
+	if !filter.afterID.IsZero() {
+		query = append(query, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: filter.afterID}}})
+	}
+	opts := options.Find().
+		SetLimit(int64(limit + 1)).
+		SetSkip(int64(filter.Offset)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	if filter.Query != "" {
+		textScore := bson.D{{Key: "$meta", Value: "textScore"}}
+		query = append(query, bson.E{Key: "$text", Value: bson.D{{Key: "$search", Value: filter.Query}}})
+		opts.SetProjection(bson.D{{Key: "score", Value: textScore}}).
+			SetSort(bson.D{{Key: "score", Value: textScore}, {Key: "_id", Value: 1}})
+	}
+	cursor, err := r.db.Collection(apartmentCollectionName).Find(ctx, query, opts)
 	if err != nil {
 		return nil, ErrDatabase
 	}
-	apartments := make([]Apartment, 0, limit)
+	apartments := make([]Apartment, 0, limit+1)
 	err = cursor.All(ctx, &apartments)
 	if err != nil {
 		return nil, ErrDatabase
 	}
-	return apartments, nil
+
+	page := &ApartmentsPage{Apartments: apartments}
+	if len(apartments) > limit {
+		page.Apartments = apartments[:limit]
+		page.HasMore = true
+		if filter.Query != "" {
+			page.NextCursor = encodeListCursor(listCursor{Offset: filter.Offset + limit})
+		} else {
+			page.NextCursor = encodeListCursor(listCursor{AfterID: apartments[limit-1].ID})
+		}
+	}
+	return page, nil
 }
 
 func (r *MongoRepositoryApartments) GetApartmentByID(ctx context.Context, apartmentID string) (a *Apartment, err error) {
 	objectID, err := primitive.ObjectIDFromHex(apartmentID)
 	if err != nil {
-		return nil, err
+		return nil, ErrWrongIDFormat
 	}
 
-	result := r.db.Collection(apartmentCollectionName).FindOne(ctx, bson.D{{"_id", objectID}})
+	result := r.db.Collection(apartmentCollectionName).FindOne(ctx, bson.D{{Key: "_id", Value: objectID}})
 	var apartment Apartment
 	err = result.Decode(&apartment)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrApartmentNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, ErrDatabase
 	}
 	return &apartment, nil
 }
+
+func (r *MongoRepositoryApartments) GetApartmentsByIDs(ctx context.Context, apartmentIDs []string) ([]Apartment, error) {
+	objectIDs := make([]primitive.ObjectID, 0, len(apartmentIDs))
+	for _, id := range apartmentIDs {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+	apartments := make([]Apartment, 0, len(objectIDs))
+	if len(objectIDs) == 0 {
+		return apartments, nil
+	}
+	query := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: objectIDs}}}}
+	cursor, err := r.db.Collection(apartmentCollectionName).Find(ctx, query)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	err = cursor.All(ctx, &apartments)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	return apartments, nil
+}
+
+func (r *MongoRepositoryApartments) SearchApartments(ctx context.Context, filter SearchFilter) (*SearchResult, error) {
+	if filter.Limit > maxApartmentLimit {
+		filter.Limit = maxApartmentLimit
+	}
+	query := searchQuery(filter)
+	collection := r.db.Collection(apartmentCollectionName)
+
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+
+	opts := options.Find().
+		SetSort(searchSort(filter.Sort)).
+		SetLimit(int64(filter.Limit)).
+		SetSkip(int64(filter.Offset))
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	apartments := make([]Apartment, 0, filter.Limit)
+	err = cursor.All(ctx, &apartments)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	return &SearchResult{Apartments: apartments, Total: total}, nil
+}
+
+func searchQuery(filter SearchFilter) bson.D {
+	query := bson.D{{Key: "status", Value: StatusPublished}}
+	if filter.City != "" {
+		query = append(query, bson.E{Key: "city", Value: filter.City})
+	}
+	price := bson.D{}
+	if filter.MinPrice > 0 {
+		price = append(price, bson.E{Key: "$gte", Value: filter.MinPrice})
+	}
+	if filter.MaxPrice > 0 {
+		price = append(price, bson.E{Key: "$lte", Value: filter.MaxPrice})
+	}
+	if len(price) > 0 {
+		query = append(query, bson.E{Key: "price", Value: price})
+	}
+	if filter.Guests > 0 {
+		query = append(query, bson.E{Key: "capacity", Value: bson.D{{Key: "$gte", Value: filter.Guests}}})
+	}
+	if filter.MinBedrooms > 0 {
+		query = append(query, bson.E{Key: "bedrooms", Value: bson.D{{Key: "$gte", Value: filter.MinBedrooms}}})
+	}
+	if len(filter.Amenities) > 0 {
+		query = append(query, bson.E{Key: "amenities", Value: bson.D{{Key: "$all", Value: filter.Amenities}}})
+	}
+	if filter.MinRating > 0 {
+		query = append(query, bson.E{Key: "rating", Value: bson.D{{Key: "$gte", Value: filter.MinRating}}})
+	}
+	if filter.InstantBook {
+		query = append(query, bson.E{Key: "instantBook", Value: true})
+	}
+	if len(filter.excludedIDs) > 0 {
+		query = append(query, bson.E{Key: "_id", Value: bson.D{{Key: "$nin", Value: filter.excludedIDs}}})
+	}
+	return query
+}
+
+// searchSort always ends with _id so that pages stay stable for equal sort values.
+func searchSort(order SortOrder) bson.D {
+	switch order {
+	case SortPriceAsc:
+		return bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}}
+	case SortPriceDesc:
+		return bson.D{{Key: "price", Value: -1}, {Key: "_id", Value: 1}}
+	case SortRating:
+		return bson.D{{Key: "rating", Value: -1}, {Key: "_id", Value: 1}}
+	case SortNewest:
+		return bson.D{{Key: "created", Value: -1}, {Key: "_id", Value: -1}}
+	default:
+		return bson.D{{Key: "_id", Value: 1}}
+	}
+}
+
+func (r *MongoRepositoryApartments) GetApartmentsNear(ctx context.Context, query NearQuery) ([]ApartmentWithDistance, error) {
+	published := bson.D{{Key: "status", Value: StatusPublished}}
+	return r.geoNear(ctx, NewGeoPoint(query.Latitude, query.Longitude), query.Radius, published, query.Limit)
+}
+
+func (r *MongoRepositoryApartments) GetApartmentsWithin(ctx context.Context, box BoundingBox) ([]ApartmentWithDistance, error) {
+	polygon := bson.D{
+		{Key: "type", Value: "Polygon"},
+		{Key: "coordinates", Value: bson.A{bson.A{
+			bson.A{box.MinLongitude, box.MinLatitude},
+			bson.A{box.MaxLongitude, box.MinLatitude},
+			bson.A{box.MaxLongitude, box.MaxLatitude},
+			bson.A{box.MinLongitude, box.MaxLatitude},
+			bson.A{box.MinLongitude, box.MinLatitude},
+		}}},
+	}
+	query := bson.D{
+		{Key: "status", Value: StatusPublished},
+		{Key: "location", Value: bson.D{{Key: "$geoWithin", Value: bson.D{{Key: "$geometry", Value: polygon}}}}},
+	}
+	return r.geoNear(ctx, box.Center(), 0, query, box.Limit)
+}
+
+// geoNear returns apartments matching query sorted by the distance from point. maxDistance of 0 means no limit.
+func (r *MongoRepositoryApartments) geoNear(ctx context.Context, point *GeoPoint, maxDistance float64, query bson.D, limit int) ([]ApartmentWithDistance, error) { //nolint:lll
+	if limit > maxApartmentLimit {
+		limit = maxApartmentLimit
+	}
+	geoNear := bson.D{
+		{Key: "near", Value: point},
+		{Key: "distanceField", Value: "distance"},
+		{Key: "spherical", Value: true},
+		{Key: "query", Value: query},
+	}
+	if maxDistance > 0 {
+		geoNear = append(geoNear, bson.E{Key: "maxDistance", Value: maxDistance})
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: geoNear}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.db.Collection(apartmentCollectionName).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	apartments := make([]ApartmentWithDistance, 0, limit)
+	err = cursor.All(ctx, &apartments)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	return apartments, nil
+}
+
+// EnsureIndexes creates the indexes used by apartment queries. It is safe to call on every start.
+func (r *MongoRepositoryApartments) EnsureIndexes(ctx context.Context) error {
+	_, err := r.db.Collection(apartmentCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "city", Value: 1}, {Key: "price", Value: 1}},
+			Options: options.Index().SetName("status_city_price"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "city", Value: 1}, {Key: "rating", Value: -1}},
+			Options: options.Index().SetName("status_city_rating"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "city", Value: 1}, {Key: "created", Value: -1}},
+			Options: options.Index().SetName("status_city_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "city", Value: 1}, {Key: "capacity", Value: 1}, {Key: "bedrooms", Value: 1}},
+			Options: options.Index().SetName("status_city_capacity_bedrooms"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "city", Value: 1}, {Key: "instantBook", Value: 1}, {Key: "price", Value: 1}},
+			Options: options.Index().SetName("status_city_instantBook_price"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "amenities", Value: 1}, {Key: "city", Value: 1}},
+			Options: options.Index().SetName("status_amenities_city"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "city", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("status_city_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "created", Value: -1}},
+			Options: options.Index().SetName("owner_created"),
+		},
+		{
+			Keys: bson.D{{Key: "owner", Value: 1}, {Key: "externalRef", Value: 1}},
+			Options: options.Index().
+				SetName("owner_externalRef").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "externalRef", Value: bson.D{{Key: "$exists", Value: true}}}}),
+		},
+		{
+			Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
+			Options: options.Index().SetName("location_2dsphere"),
+		},
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "address", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("title_address_description_text").
+				SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "address", Value: 5}, {Key: "description", Value: 1}}),
+		},
+	})
+	return err
+}
+
+func (r *MongoRepositoryApartments) CreateApartment(ctx context.Context, apartment *Apartment) (*Apartment, error) {
+	result, err := r.db.Collection(apartmentCollectionName).InsertOne(ctx, apartment)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	apartment.ID = result.InsertedID.(primitive.ObjectID)
+	return apartment, nil
+}
+
+// UpdateApartment saves the owner editable fields and the status of the apartment, leaving photos and other
+// separately managed fields untouched. It only matches the apartment while its status still is from, so an edit
+// does not overwrite a concurrent status transition.
+func (r *MongoRepositoryApartments) UpdateApartment(ctx context.Context, apartment *Apartment, from ListingStatus) error {
+	set, unset := editableFields(apartment)
+	set = append(set, bson.E{Key: "status", Value: apartment.Status})
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+	result, err := r.db.Collection(apartmentCollectionName).UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: apartment.ID}, {Key: "status", Value: from}},
+		update,
+	)
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidStatusTransition
+	}
+	return nil
+}
+
+// UpsertApartmentByExternalRef creates or updates the apartment of apartment.Owner with the same ExternalRef.
+// Existing apartments keep their status unless apartment.Status is set, new ones default to drafts.
+func (r *MongoRepositoryApartments) UpsertApartmentByExternalRef(ctx context.Context, apartment *Apartment) (created bool, err error) { //nolint:lll
+	filter, update := externalRefUpsert(apartment)
+	result, err := r.db.Collection(apartmentCollectionName).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, ErrDatabase
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// externalRefUpsert builds the upsert of UpsertApartmentByExternalRef. Upserting the same apartment again
+// sets the same fields, creation date and photos are only written when the apartment is inserted.
+func externalRefUpsert(apartment *Apartment) (filter, update bson.D) {
+	set, unset := editableFields(apartment)
+	setOnInsert := bson.D{{Key: "created", Value: apartment.Created}, {Key: "photos", Value: []Photo{}}}
+	if apartment.Status != "" {
+		set = append(set, bson.E{Key: "status", Value: apartment.Status})
+	} else {
+		setOnInsert = append(setOnInsert, bson.E{Key: "status", Value: StatusDraft})
+	}
+	update = bson.D{{Key: "$set", Value: set}, {Key: "$setOnInsert", Value: setOnInsert}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+	return bson.D{{Key: "owner", Value: apartment.Owner}, {Key: "externalRef", Value: apartment.ExternalRef}}, update
+}
+
+// ExportApartments calls fn for every apartment matching the filter, in _id order, stopping at the first error.
+func (r *MongoRepositoryApartments) ExportApartments(ctx context.Context, filter ExportFilter, fn func(*Apartment) error) error {
+	query := bson.D{}
+	if filter.City != "" {
+		query = append(query, bson.E{Key: "city", Value: canonicalCity(filter.City)})
+	}
+	if filter.Status != "" {
+		query = append(query, bson.E{Key: "status", Value: filter.Status})
+	}
+	if filter.Owner != "" {
+		query = append(query, bson.E{Key: "owner", Value: filter.Owner})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.db.Collection(apartmentCollectionName).Find(ctx, query, opts)
+	if err != nil {
+		return ErrDatabase
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+	for cursor.Next(ctx) {
+		var apartment Apartment
+		if err = cursor.Decode(&apartment); err != nil {
+			return ErrDatabase
+		}
+		if err = fn(&apartment); err != nil {
+			return err
+		}
+	}
+	if cursor.Err() != nil {
+		return ErrDatabase
+	}
+	return nil
+}
+
+// editableFields returns the $set and $unset documents of the owner editable fields of the apartment.
+func editableFields(apartment *Apartment) (set, unset bson.D) {
+	set = bson.D{
+		{Key: "title", Value: apartment.Title},
+		{Key: "description", Value: apartment.Description},
+		{Key: "address", Value: apartment.Address},
+		{Key: "city", Value: apartment.City},
+		{Key: "cityId", Value: apartment.CityID},
+		{Key: "country", Value: apartment.Country},
+		{Key: "timeZone", Value: apartment.TimeZone},
+		{Key: "price", Value: apartment.Price},
+		{Key: "capacity", Value: apartment.Capacity},
+		{Key: "bedrooms", Value: apartment.Bedrooms},
+		{Key: "amenities", Value: apartment.Amenities},
+		{Key: "instantBook", Value: apartment.InstantBook},
+	}
+	if apartment.Location != nil {
+		set = append(set, bson.E{Key: "location", Value: apartment.Location})
+	} else {
+		unset = bson.D{{Key: "location", Value: ""}}
+	}
+	return set, unset
+}
+
+func (r *MongoRepositoryApartments) DeleteApartment(ctx context.Context, apartmentID string) error {
+	objectID, err := primitive.ObjectIDFromHex(apartmentID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	result, err := r.db.Collection(apartmentCollectionName).DeleteOne(ctx, bson.D{{Key: "_id", Value: objectID}})
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.DeletedCount == 0 {
+		return ErrApartmentNotFound
+	}
+	return nil
+}
+
+// UpdateStatus changes the apartment status only if it still is from, so that concurrent transitions do not overwrite each other.
+func (r *MongoRepositoryApartments) UpdateStatus(ctx context.Context, apartmentID string, from, to ListingStatus) error {
+	objectID, err := primitive.ObjectIDFromHex(apartmentID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	result, err := r.db.Collection(apartmentCollectionName).UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: objectID}, {Key: "status", Value: from}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: to}}}},
+	)
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidStatusTransition
+	}
+	return nil
+}
+
+func (r *MongoRepositoryApartments) GetApartmentsByOwner(ctx context.Context, ownerID string) ([]Apartment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created", Value: -1}})
+	cursor, err := r.db.Collection(apartmentCollectionName).Find(ctx, bson.D{{Key: "owner", Value: ownerID}}, opts)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	apartments := make([]Apartment, 0)
+	err = cursor.All(ctx, &apartments)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	return apartments, nil
+}
+
+func (r *MongoRepositoryApartments) GetApartmentsByStatus(ctx context.Context, status ListingStatus, limit, offset int) ([]Apartment, error) { //nolint:lll
+	if limit <= 0 || limit > maxApartmentLimit {
+		limit = maxApartmentLimit
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+	cursor, err := r.db.Collection(apartmentCollectionName).Find(ctx, bson.D{{Key: "status", Value: status}}, opts)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	apartments := make([]Apartment, 0, limit)
+	err = cursor.All(ctx, &apartments)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	return apartments, nil
+}
+
+// AddPhoto pushes the photo only while the apartment has fewer than maxPhotos photos, so that concurrent uploads
+// can not go over the limit.
+func (r *MongoRepositoryApartments) AddPhoto(ctx context.Context, apartmentID string, photo *Photo, setCover bool, maxPhotos int) error {
+	objectID, err := primitive.ObjectIDFromHex(apartmentID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	update := bson.D{{Key: "$push", Value: bson.D{{Key: "photos", Value: photo}}}}
+	if setCover {
+		update = append(update, bson.E{Key: "$set", Value: bson.D{{Key: "coverPhotoId", Value: photo.ID}}})
+	}
+	// The apartment has fewer than maxPhotos photos when it has no photo at the index maxPhotos-1.
+	filter := bson.D{
+		{Key: "_id", Value: objectID},
+		{Key: "photos." + strconv.Itoa(maxPhotos-1), Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	result, err := r.db.Collection(apartmentCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.db.Collection(apartmentCollectionName).CountDocuments(ctx, bson.D{{Key: "_id", Value: objectID}})
+		if err != nil {
+			return ErrDatabase
+		}
+		if count > 0 {
+			return ErrTooManyPhotos
+		}
+		return ErrApartmentNotFound
+	}
+	return nil
+}
+
+func (r *MongoRepositoryApartments) UpdatePhotos(ctx context.Context, apartmentID string, photos []Photo, coverPhotoID string) error {
+	objectID, err := primitive.ObjectIDFromHex(apartmentID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "photos", Value: photos},
+		{Key: "coverPhotoId", Value: coverPhotoID},
+	}}}
+	result, err := r.db.Collection(apartmentCollectionName).UpdateOne(ctx, bson.D{{Key: "_id", Value: objectID}}, update)
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.MatchedCount == 0 {
+		return ErrApartmentNotFound
+	}
+	return nil
+}
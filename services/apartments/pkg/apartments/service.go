@@ -3,44 +3,626 @@ package apartments
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"contracts/pkg/contracts"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	maxTitleLength   = 120
+	minTitleLength   = 3
+	maxAddressLength = 200
+	maxCityLength    = 60
+	maxDescription   = 5000
+	maxQueryLength   = 200
+	maxPrice         = 100000
+	maxCapacity      = 50
+	maxBedrooms      = 50
+	maxAmenities     = 50
+	maxRating        = 5
+	maxLatitude      = 90
+	maxLongitude     = 180
+)
+
 var ErrDatabase = errors.New("error requesting data from db")
+var ErrWrongIDFormat = errors.New("wrong id format")
+var ErrApartmentNotFound = errors.New("apartment not found")
+var ErrNotApartmentOwner = errors.New("apartment can be changed only by its owner")
+var ErrApartmentHasReservations = errors.New("apartment has future reservations")
+var ErrCouldNotCheckReservations = errors.New("could not check apartment reservations")
+
+// ValidationError is returned when apartment fields sent by a client are not valid.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Reason
+}
 
 type City string
 
 type Apartment struct {
-	ID      primitive.ObjectID `json:"_id" bson:"_id"`
-	Title   string             `json:"title"`
-	Address string             `json:"address"`
-	Owner   string             `json:"owner"`
-	City    string             `json:"city"`
+	ID          primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Address     string             `json:"address"`
+	Owner       string             `json:"owner"`
+	Status      ListingStatus      `json:"status"`
+	City        string             `json:"city"`
+	// CityID, Country and TimeZone come from the cities catalog entry of City.
+	CityID      string    `json:"cityId" bson:"cityId"`
+	Country     string    `json:"country"`
+	TimeZone    string    `json:"timeZone" bson:"timeZone"`
+	Price       float64   `json:"price"`
+	Capacity    int       `json:"capacity"`
+	Bedrooms    int       `json:"bedrooms"`
+	Amenities   []string  `json:"amenities"`
+	Rating      float64   `json:"rating"`
+	InstantBook bool      `json:"instantBook" bson:"instantBook"`
+	Location    *GeoPoint `json:"location,omitempty" bson:"location,omitempty"`
+	Photos      []Photo   `json:"photos"`
+	// CoverPhotoID is the id of the photo from Photos shown first in listings.
+	CoverPhotoID string `json:"coverPhotoId,omitempty" bson:"coverPhotoId,omitempty"`
+	// ExternalRef is the id of the apartment in the system of its property manager, unique per owner.
+	ExternalRef string    `json:"externalRef,omitempty" bson:"externalRef,omitempty"`
+	Created     time.Time `json:"created"`
+	// Saved tells the user the apartment is listed for that it is in one of their wishlists, it is never stored.
+	Saved bool `json:"saved" bson:"-"`
+}
+
+// GeoPoint is a GeoJSON point. Coordinates are stored as [longitude, latitude].
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+func NewGeoPoint(lat, lng float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+// ApartmentWithDistance is an apartment found by a geo query, Distance is in meters.
+type ApartmentWithDistance struct {
+	Apartment `bson:",inline"`
+	Distance  float64 `json:"distance" bson:"distance"`
+}
+
+// ApartmentDetails holds the fields of an apartment an owner is allowed to edit.
+type ApartmentDetails struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Address     string   `json:"address"`
+	City        string   `json:"city"`
+	Price       float64  `json:"price"`
+	Capacity    int      `json:"capacity"`
+	Bedrooms    int      `json:"bedrooms"`
+	Amenities   []string `json:"amenities"`
+	InstantBook bool     `json:"instantBook"`
+	Latitude    *float64 `json:"lat,omitempty"`
+	Longitude   *float64 `json:"lng,omitempty"`
+}
+
+// Normalize trims the details and checks them, returning a *ValidationError for the first invalid field.
+func (d *ApartmentDetails) Normalize() error {
+	d.Title = strings.TrimSpace(d.Title)
+	d.Description = strings.TrimSpace(d.Description)
+	d.Address = strings.TrimSpace(d.Address)
+	d.City = strings.TrimSpace(d.City)
+	d.Amenities = normalizeAmenities(d.Amenities)
+
+	switch {
+	case len(d.Title) < minTitleLength:
+		return &ValidationError{Field: "title", Reason: fmt.Sprintf("must be at least %d characters long", minTitleLength)}
+	case len(d.Title) > maxTitleLength:
+		return &ValidationError{Field: "title", Reason: fmt.Sprintf("must be at most %d characters long", maxTitleLength)}
+	case len(d.Description) > maxDescription:
+		return &ValidationError{Field: "description", Reason: fmt.Sprintf("must be at most %d characters long", maxDescription)}
+	case d.Address == "":
+		return &ValidationError{Field: "address", Reason: "is required"}
+	case len(d.Address) > maxAddressLength:
+		return &ValidationError{Field: "address", Reason: fmt.Sprintf("must be at most %d characters long", maxAddressLength)}
+	case d.City == "":
+		return &ValidationError{Field: "city", Reason: "is required"}
+	case len(d.City) > maxCityLength:
+		return &ValidationError{Field: "city", Reason: fmt.Sprintf("must be at most %d characters long", maxCityLength)}
+	case d.city() == nil:
+		return &ValidationError{Field: "city", Reason: "unknown city " + d.City}
+	case d.Price <= 0 || d.Price > maxPrice:
+		return &ValidationError{Field: "price", Reason: fmt.Sprintf("must be greater than 0 and at most %d", maxPrice)}
+	case d.Capacity < 1 || d.Capacity > maxCapacity:
+		return &ValidationError{Field: "capacity", Reason: fmt.Sprintf("must be between 1 and %d", maxCapacity)}
+	case d.Bedrooms < 0 || d.Bedrooms > maxBedrooms:
+		return &ValidationError{Field: "bedrooms", Reason: fmt.Sprintf("must be between 0 and %d", maxBedrooms)}
+	case len(d.Amenities) > maxAmenities:
+		return &ValidationError{Field: "amenities", Reason: fmt.Sprintf("must contain at most %d items", maxAmenities)}
+	case (d.Latitude == nil) != (d.Longitude == nil):
+		return &ValidationError{Field: "lat", Reason: "lat and lng must be set together"}
+	case d.Latitude != nil && !validLatitude(*d.Latitude):
+		return &ValidationError{Field: "lat", Reason: fmt.Sprintf("must be between -%d and %d", maxLatitude, maxLatitude)}
+	case d.Longitude != nil && !validLongitude(*d.Longitude):
+		return &ValidationError{Field: "lng", Reason: fmt.Sprintf("must be between -%d and %d", maxLongitude, maxLongitude)}
+	}
+	return validateAmenities("amenities", d.Amenities)
+}
+
+func (d *ApartmentDetails) city() *CityInfo {
+	city, _ := LookupCity(d.City)
+	return city
+}
+
+// apply copies normalized details to a, City is replaced by its canonical catalog name.
+func (d *ApartmentDetails) apply(a *Apartment) {
+	a.Title = d.Title
+	a.Description = d.Description
+	a.Address = d.Address
+	if city := d.city(); city != nil {
+		a.City, a.CityID, a.Country, a.TimeZone = city.Name, city.ID, city.Country, city.TimeZone
+	}
+	a.Price = d.Price
+	a.Capacity = d.Capacity
+	a.Bedrooms = d.Bedrooms
+	a.Amenities = d.Amenities
+	a.InstantBook = d.InstantBook
+	a.Location = nil
+	if d.Latitude != nil && d.Longitude != nil {
+		a.Location = NewGeoPoint(*d.Latitude, *d.Longitude)
+	}
+}
+
+func validLatitude(lat float64) bool {
+	return lat >= -maxLatitude && lat <= maxLatitude
+}
+
+func validLongitude(lng float64) bool {
+	return lng >= -maxLongitude && lng <= maxLongitude
+}
+
+// normalizeAmenities lowercases amenity codes and drops empty and duplicated ones, keeping the original order.
+func normalizeAmenities(amenities []string) []string {
+	seen := make(map[string]bool, len(amenities))
+	normalized := make([]string, 0, len(amenities))
+	for _, amenity := range amenities {
+		amenity = strings.ToLower(strings.TrimSpace(amenity))
+		if amenity == "" || seen[amenity] {
+			continue
+		}
+		seen[amenity] = true
+		normalized = append(normalized, amenity)
+	}
+	return normalized
+}
+
+// ListFilter describes the apartments listing. An empty City lists all cities, a non-empty Query
+// runs a full-text search over titles, descriptions and addresses and orders results by relevance.
+// Cursor, returned as ApartmentsPage.NextCursor, takes precedence over Offset.
+// ViewerID, when set, marks the apartments the viewer saved.
+type ListFilter struct {
+	ViewerID  string
+	City      City
+	Query     string
+	Amenities []string
+	Cursor    string
+	Limit     int
+	Offset    int
+
+	afterID primitive.ObjectID
+}
+
+func (f *ListFilter) Normalize() error {
+	f.City = canonicalCity(f.City)
+	f.Query = strings.TrimSpace(f.Query)
+	f.Amenities = normalizeAmenities(f.Amenities)
+	if f.Cursor != "" {
+		cursor, err := decodeListCursor(f.Cursor)
+		if err != nil {
+			return err
+		}
+		if f.Query != "" && !cursor.AfterID.IsZero() {
+			return &ValidationError{Field: "cursor", Reason: "does not belong to this listing"}
+		}
+		f.afterID, f.Offset = cursor.AfterID, cursor.Offset
+	}
+	if f.Limit == 0 {
+		f.Limit = defaultSearchLimit
+	}
+
+	switch {
+	case len(f.Query) > maxQueryLength:
+		return &ValidationError{Field: "q", Reason: fmt.Sprintf("must be at most %d characters long", maxQueryLength)}
+	case f.Limit < 0:
+		return &ValidationError{Field: "limit", Reason: "must not be negative"}
+	case f.Offset < 0:
+		return &ValidationError{Field: "offset", Reason: "must not be negative"}
+	}
+	return validateAmenities("amenities", f.Amenities)
+}
+
+type ApartmentsPage struct {
+	Apartments []Apartment `json:"apartments"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	HasMore    bool        `json:"hasMore"`
+}
+
+type SortOrder string
+
+const (
+	SortPriceAsc  SortOrder = "price_asc"
+	SortPriceDesc SortOrder = "price_desc"
+	SortRating    SortOrder = "rating"
+	SortNewest    SortOrder = "newest"
+)
+
+const defaultSearchLimit = 20
+
+// availabilityTimeout bounds the wait for busy apartments from the booking service during a search.
+const availabilityTimeout = 2 * time.Second
+
+// SearchFilter describes a search over apartments. Zero values mean "no restriction".
+type SearchFilter struct {
+	ViewerID    string
+	City        City
+	MinPrice    float64
+	MaxPrice    float64
+	Guests      int
+	MinBedrooms int
+	Amenities   []string
+	MinRating   float64
+	InstantBook bool
+	Sort        SortOrder
+	Limit       int
+	Offset      int
+	// CheckIn and CheckOut, when both set, exclude apartments booked in that window.
+	CheckIn  time.Time
+	CheckOut time.Time
+
+	excludedIDs []primitive.ObjectID
+}
+
+func (f *SearchFilter) hasStayDates() bool {
+	return !f.CheckIn.IsZero() && !f.CheckOut.IsZero()
+}
+
+// Normalize fills defaults and checks the filter, returning a *ValidationError for the first invalid field.
+func (f *SearchFilter) Normalize() error {
+	f.City = canonicalCity(f.City)
+	f.Amenities = normalizeAmenities(f.Amenities)
+	if f.Limit <= 0 {
+		f.Limit = defaultSearchLimit
+	}
+
+	switch {
+	case f.MinPrice < 0:
+		return &ValidationError{Field: "minPrice", Reason: "must not be negative"}
+	case f.MaxPrice < 0:
+		return &ValidationError{Field: "maxPrice", Reason: "must not be negative"}
+	case f.MaxPrice > 0 && f.MinPrice > f.MaxPrice:
+		return &ValidationError{Field: "maxPrice", Reason: "must not be less than minPrice"}
+	case f.Guests < 0:
+		return &ValidationError{Field: "guests", Reason: "must not be negative"}
+	case f.MinBedrooms < 0:
+		return &ValidationError{Field: "bedrooms", Reason: "must not be negative"}
+	case f.MinRating < 0 || f.MinRating > maxRating:
+		return &ValidationError{Field: "minRating", Reason: fmt.Sprintf("must be between 0 and %d", maxRating)}
+	case f.Offset < 0:
+		return &ValidationError{Field: "offset", Reason: "must not be negative"}
+	case f.CheckIn.IsZero() != f.CheckOut.IsZero():
+		return &ValidationError{Field: "checkIn", Reason: "checkIn and checkOut must be set together"}
+	case f.hasStayDates() && !f.CheckOut.After(f.CheckIn):
+		return &ValidationError{Field: "checkOut", Reason: "must be after checkIn"}
+	case f.hasStayDates() && f.CheckOut.Sub(f.CheckIn) > contracts.MaxBusyApartmentsWindow:
+		return &ValidationError{
+			Field:  "checkOut",
+			Reason: fmt.Sprintf("must be at most %d days after checkIn", contracts.MaxBusyApartmentsWindow/(24*time.Hour)),
+		}
+	}
+
+	switch f.Sort {
+	case "", SortPriceAsc, SortPriceDesc, SortRating, SortNewest:
+	default:
+		return &ValidationError{Field: "sort", Reason: "must be one of price_asc, price_desc, rating, newest"}
+	}
+	return validateAmenities("amenities", f.Amenities)
+}
+
+const (
+	defaultNearRadius = 5000
+	maxNearRadius     = 100000
+)
+
+// NearQuery looks for apartments within Radius meters from a point.
+type NearQuery struct {
+	ViewerID  string
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+	Limit     int
+}
+
+func (q *NearQuery) Normalize() error {
+	if q.Radius == 0 {
+		q.Radius = defaultNearRadius
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+
+	switch {
+	case !validLatitude(q.Latitude):
+		return &ValidationError{Field: "lat", Reason: fmt.Sprintf("must be between -%d and %d", maxLatitude, maxLatitude)}
+	case !validLongitude(q.Longitude):
+		return &ValidationError{Field: "lng", Reason: fmt.Sprintf("must be between -%d and %d", maxLongitude, maxLongitude)}
+	case q.Radius < 0 || q.Radius > maxNearRadius:
+		return &ValidationError{Field: "radius", Reason: fmt.Sprintf("must be between 0 and %d meters", maxNearRadius)}
+	}
+	return nil
+}
+
+// BoundingBox looks for apartments inside a map viewport, results are sorted by the distance from its center.
+type BoundingBox struct {
+	ViewerID     string
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+	Limit        int
+}
+
+func (b *BoundingBox) Normalize() error {
+	if b.Limit <= 0 {
+		b.Limit = defaultSearchLimit
+	}
+
+	switch {
+	case !validLatitude(b.MinLatitude) || !validLatitude(b.MaxLatitude):
+		return &ValidationError{Field: "minLat", Reason: fmt.Sprintf("latitudes must be between -%d and %d", maxLatitude, maxLatitude)}
+	case !validLongitude(b.MinLongitude) || !validLongitude(b.MaxLongitude):
+		return &ValidationError{Field: "minLng", Reason: fmt.Sprintf("longitudes must be between -%d and %d", maxLongitude, maxLongitude)}
+	case b.MinLatitude >= b.MaxLatitude:
+		return &ValidationError{Field: "maxLat", Reason: "must be greater than minLat"}
+	case b.MinLongitude >= b.MaxLongitude:
+		return &ValidationError{Field: "maxLng", Reason: "must be greater than minLng"}
+	}
+	return nil
+}
+
+func (b *BoundingBox) Center() *GeoPoint {
+	return NewGeoPoint((b.MinLatitude+b.MaxLatitude)/2, (b.MinLongitude+b.MaxLongitude)/2)
+}
+
+type SearchResult struct {
+	Apartments []Apartment `json:"apartments"`
+	Total      int64       `json:"total"`
+	// AvailabilityDegraded is set when stay dates were requested but the booking service
+	// did not answer in time, so the results are not filtered by availability.
+	AvailabilityDegraded bool `json:"availabilityDegraded,omitempty"`
 }
 
 type Service interface {
-	GetApartments(ctx context.Context, city City, limit, offset int) ([]Apartment, error)
-	GetApartmentByID(ctx context.Context, apartmentID string) (*Apartment, error)
+	GetApartments(ctx context.Context, filter ListFilter) (*ApartmentsPage, error)
+	GetApartmentByID(ctx context.Context, viewerID, apartmentID string) (*Apartment, error)
+	SearchApartments(ctx context.Context, filter SearchFilter) (*SearchResult, error)
+	GetApartmentsNear(ctx context.Context, query NearQuery) ([]ApartmentWithDistance, error)
+	GetApartmentsWithin(ctx context.Context, box BoundingBox) ([]ApartmentWithDistance, error)
+	GetOwnerApartments(ctx context.Context, ownerID string) ([]Apartment, error)
+	GetApartmentsForModeration(ctx context.Context, isAdmin bool, status ListingStatus, limit, offset int) ([]Apartment, error)
+	ChangeApartmentStatus(ctx context.Context, actorID string, isAdmin bool, apartmentID string, status ListingStatus) (*Apartment, error)
+	GetAmenities(ctx context.Context, language string) ([]LocalizedAmenity, error)
+	GetCities(ctx context.Context, prefix string, limit int) ([]CityInfo, error)
+	CreateApartment(ctx context.Context, ownerID string, details ApartmentDetails) (*Apartment, error)
+	UpdateApartment(ctx context.Context, ownerID, apartmentID string, details ApartmentDetails) (*Apartment, error)
+	DeleteApartment(ctx context.Context, ownerID, apartmentID string) error
+	AddPhoto(ctx context.Context, ownerID, apartmentID string, data []byte) (*Photo, error)
+	DeletePhoto(ctx context.Context, ownerID, apartmentID, photoID string) (*Apartment, error)
+	ReorderPhotos(ctx context.Context, ownerID, apartmentID string, photoIDs []string) (*Apartment, error)
+	SetCoverPhoto(ctx context.Context, ownerID, apartmentID, photoID string) (*Apartment, error)
+	GetWishlists(ctx context.Context, userID string) ([]Wishlist, error)
+	CreateWishlist(ctx context.Context, userID, name string) (*Wishlist, error)
+	GetWishlist(ctx context.Context, userID, wishlistID string) (*WishlistView, error)
+	GetSharedWishlist(ctx context.Context, token string) (*WishlistView, error)
+	DeleteWishlist(ctx context.Context, userID, wishlistID string) error
+	AddToWishlist(ctx context.Context, userID, wishlistID, apartmentID string) (*Wishlist, error)
+	RemoveFromWishlist(ctx context.Context, userID, wishlistID, apartmentID string) (*Wishlist, error)
+	ShareWishlist(ctx context.Context, userID, wishlistID string, shared bool) (*Wishlist, error)
 }
 
 type Repository interface {
-	GetApartmentsByCity(ctx context.Context, city City, limit, offset int) ([]Apartment, error)
+	GetApartmentsByCity(ctx context.Context, filter ListFilter) (*ApartmentsPage, error)
 	GetApartmentByID(ctx context.Context, apartmentID string) (*Apartment, error)
+	// GetApartmentsByIDs returns the apartments with the given ids whatever their status, missing ones are skipped.
+	GetApartmentsByIDs(ctx context.Context, apartmentIDs []string) ([]Apartment, error)
+	SearchApartments(ctx context.Context, filter SearchFilter) (*SearchResult, error)
+	GetApartmentsNear(ctx context.Context, query NearQuery) ([]ApartmentWithDistance, error)
+	GetApartmentsWithin(ctx context.Context, box BoundingBox) ([]ApartmentWithDistance, error)
+	CreateApartment(ctx context.Context, apartment *Apartment) (*Apartment, error)
+	UpdateApartment(ctx context.Context, apartment *Apartment, from ListingStatus) error
+	DeleteApartment(ctx context.Context, apartmentID string) error
+	UpdateStatus(ctx context.Context, apartmentID string, from, to ListingStatus) error
+	GetApartmentsByOwner(ctx context.Context, ownerID string) ([]Apartment, error)
+	GetApartmentsByStatus(ctx context.Context, status ListingStatus, limit, offset int) ([]Apartment, error)
+	// AddPhoto appends the photo unless the apartment already has maxPhotos, see ErrTooManyPhotos.
+	AddPhoto(ctx context.Context, apartmentID string, photo *Photo, setCover bool, maxPhotos int) error
+	UpdatePhotos(ctx context.Context, apartmentID string, photos []Photo, coverPhotoID string) error
+}
+
+// BookingRepository gives access to reservations kept by the booking service.
+type BookingRepository interface {
+	HasFutureReservations(ctx context.Context, apartmentID string) (bool, error)
+	GetBusyApartmentIDs(ctx context.Context, checkIn, checkOut time.Time) ([]string, error)
 }
 
 type service struct {
-	ar Repository
+	ar    Repository
+	br    BookingRepository
+	blobs BlobStore
+	wr    WishlistRepository
+}
+
+func NewService(ar Repository, br BookingRepository, blobs BlobStore, wr WishlistRepository) Service {
+	return &service{ar: ar, br: br, blobs: blobs, wr: wr}
+}
+
+func (s *service) GetApartments(ctx context.Context, filter ListFilter) (*ApartmentsPage, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+	page, err := s.ar.GetApartmentsByCity(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	s.markSaved(ctx, filter.ViewerID, apartmentRefs(page.Apartments))
+	return page, nil
+}
+
+// GetApartmentByID returns a published apartment, or any apartment of its owner when viewerID is the owner.
+func (s *service) GetApartmentByID(ctx context.Context, viewerID, apartmentID string) (*Apartment, error) {
+	apartment, err := s.ar.GetApartmentByID(ctx, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if apartment.Status != StatusPublished && (viewerID == "" || apartment.Owner != viewerID) {
+		return nil, ErrApartmentNotFound
+	}
+	return apartment, nil
+}
+
+func (s *service) SearchApartments(ctx context.Context, filter SearchFilter) (*SearchResult, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+	// Apartments are not filtered by availability while the booking service is unavailable.
+	degraded := false
+	if filter.hasStayDates() {
+		var err error
+		filter.excludedIDs, err = s.getBusyApartmentIDs(ctx, filter.CheckIn, filter.CheckOut)
+		switch {
+		case bookingUnavailable(err):
+			degraded = true
+		case err != nil:
+			return nil, ErrCouldNotCheckReservations
+		}
+	}
+	result, err := s.ar.SearchApartments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result.AvailabilityDegraded = degraded
+	s.markSaved(ctx, filter.ViewerID, apartmentRefs(result.Apartments))
+	return result, nil
+}
+
+func (s *service) getBusyApartmentIDs(ctx context.Context, checkIn, checkOut time.Time) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, availabilityTimeout)
+	defer cancel()
+	busyIDs, err := s.br.GetBusyApartmentIDs(ctx, checkIn, checkOut)
+	if err != nil {
+		return nil, err
+	}
+	objectIDs := make([]primitive.ObjectID, 0, len(busyIDs))
+	for _, id := range busyIDs {
+		objectID, idErr := primitive.ObjectIDFromHex(id)
+		if idErr != nil {
+			continue
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+	return objectIDs, nil
+}
+
+func (s *service) GetApartmentsNear(ctx context.Context, query NearQuery) ([]ApartmentWithDistance, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	apartments, err := s.ar.GetApartmentsNear(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.markSaved(ctx, query.ViewerID, distanceApartmentRefs(apartments))
+	return apartments, nil
+}
+
+func (s *service) GetApartmentsWithin(ctx context.Context, box BoundingBox) ([]ApartmentWithDistance, error) {
+	if err := box.Normalize(); err != nil {
+		return nil, err
+	}
+	apartments, err := s.ar.GetApartmentsWithin(ctx, box)
+	if err != nil {
+		return nil, err
+	}
+	s.markSaved(ctx, box.ViewerID, distanceApartmentRefs(apartments))
+	return apartments, nil
+}
+
+func (s *service) GetAmenities(_ context.Context, language string) ([]LocalizedAmenity, error) {
+	return LocalizeAmenities(language), nil
+}
+
+func (s *service) GetCities(_ context.Context, prefix string, limit int) ([]CityInfo, error) {
+	return SuggestCities(prefix, limit), nil
+}
+
+func (s *service) CreateApartment(ctx context.Context, ownerID string, details ApartmentDetails) (*Apartment, error) {
+	if err := details.Normalize(); err != nil {
+		return nil, err
+	}
+	apartment := &Apartment{Owner: ownerID, Status: StatusDraft, Created: time.Now()}
+	details.apply(apartment)
+	return s.ar.CreateApartment(ctx, apartment)
 }
 
-func NewService(ar Repository) Service {
-	return &service{ar: ar}
+// UpdateApartment replaces the details of the owner's apartment. Guests only see reviewed details, so a published
+// apartment goes back to pending review together with its new details.
+func (s *service) UpdateApartment(ctx context.Context, ownerID, apartmentID string, details ApartmentDetails) (*Apartment, error) {
+	if err := details.Normalize(); err != nil {
+		return nil, err
+	}
+	apartment, err := s.getOwnedApartment(ctx, ownerID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	details.apply(apartment)
+	from := apartment.Status
+	if from == StatusPublished {
+		apartment.Status = StatusPendingReview
+	}
+	if err = s.ar.UpdateApartment(ctx, apartment, from); err != nil {
+		return nil, err
+	}
+	return apartment, nil
 }
 
-func (s *service) GetApartments(ctx context.Context, city City, limit, offset int) ([]Apartment, error) {
-	return s.ar.GetApartmentsByCity(ctx, city, limit, offset)
+func (s *service) DeleteApartment(ctx context.Context, ownerID, apartmentID string) error {
+	apartment, err := s.getOwnedApartment(ctx, ownerID, apartmentID)
+	if err != nil {
+		return err
+	}
+	hasReservations, err := s.br.HasFutureReservations(ctx, apartmentID)
+	if err != nil {
+		return ErrCouldNotCheckReservations
+	}
+	if hasReservations {
+		return ErrApartmentHasReservations
+	}
+	if err = s.ar.DeleteApartment(ctx, apartmentID); err != nil {
+		return err
+	}
+	for _, photo := range apartment.Photos {
+		s.deleteBlobs(ctx, photo.Keys)
+	}
+	return nil
 }
 
-func (s *service) GetApartmentByID(ctx context.Context, apartmentID string) (*Apartment, error) {
-	return s.ar.GetApartmentByID(ctx, apartmentID)
+func (s *service) getOwnedApartment(ctx context.Context, ownerID, apartmentID string) (*Apartment, error) {
+	apartment, err := s.ar.GetApartmentByID(ctx, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if apartment.Owner != ownerID {
+		return nil, ErrNotApartmentOwner
+	}
+	return apartment, nil
 }
@@ -0,0 +1,510 @@
+package apartments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"contracts/pkg/contracts"
+
+	"github.com/nats-io/nats.go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryRepository keeps apartments by id, methods a test does not need panic through the nil Repository.
+type memoryRepository struct {
+	Repository
+	apartments map[string]Apartment
+	deleted    []string
+	searched   SearchFilter
+	photoErr   error
+	statuses   []ListingStatus
+}
+
+func (r *memoryRepository) GetApartmentByID(_ context.Context, apartmentID string) (*Apartment, error) {
+	apartment, ok := r.apartments[apartmentID]
+	if !ok {
+		return nil, ErrApartmentNotFound
+	}
+	return &apartment, nil
+}
+
+func (r *memoryRepository) GetApartmentsByIDs(_ context.Context, apartmentIDs []string) ([]Apartment, error) {
+	found := make([]Apartment, 0, len(apartmentIDs))
+	for _, id := range apartmentIDs {
+		if apartment, ok := r.apartments[id]; ok {
+			found = append(found, apartment)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryRepository) SearchApartments(_ context.Context, filter SearchFilter) (*SearchResult, error) {
+	r.searched = filter
+	return &SearchResult{Apartments: []Apartment{}}, nil
+}
+
+func (r *memoryRepository) UpdateApartment(_ context.Context, apartment *Apartment, from ListingStatus) error {
+	if r.apartments[apartment.ID.Hex()].Status != from {
+		return ErrInvalidStatusTransition
+	}
+	r.apartments[apartment.ID.Hex()] = *apartment
+	return nil
+}
+
+func (r *memoryRepository) AddPhoto(_ context.Context, apartmentID string, photo *Photo, setCover bool, maxPhotos int) error {
+	if r.photoErr != nil {
+		return r.photoErr
+	}
+	apartment := r.apartments[apartmentID]
+	if len(apartment.Photos) >= maxPhotos {
+		return ErrTooManyPhotos
+	}
+	apartment.Photos = append(apartment.Photos, *photo)
+	if setCover {
+		apartment.CoverPhotoID = photo.ID
+	}
+	r.apartments[apartmentID] = apartment
+	return nil
+}
+
+func (r *memoryRepository) UpdatePhotos(_ context.Context, apartmentID string, photos []Photo, coverPhotoID string) error {
+	apartment := r.apartments[apartmentID]
+	apartment.Photos, apartment.CoverPhotoID = photos, coverPhotoID
+	r.apartments[apartmentID] = apartment
+	return nil
+}
+
+func (r *memoryRepository) UpdateStatus(_ context.Context, apartmentID string, from, to ListingStatus) error {
+	apartment := r.apartments[apartmentID]
+	if apartment.Status != from {
+		return ErrInvalidStatusTransition
+	}
+	apartment.Status = to
+	r.apartments[apartmentID] = apartment
+	r.statuses = append(r.statuses, to)
+	return nil
+}
+
+func (r *memoryRepository) GetApartmentsByStatus(_ context.Context, status ListingStatus, _, _ int) ([]Apartment, error) {
+	found := make([]Apartment, 0)
+	for _, apartment := range r.apartments {
+		if apartment.Status == status {
+			found = append(found, apartment)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryRepository) DeleteApartment(_ context.Context, apartmentID string) error {
+	r.deleted = append(r.deleted, apartmentID)
+	return nil
+}
+
+type reservations struct {
+	future bool
+	busy   []string
+	err    error
+}
+
+func (r reservations) HasFutureReservations(context.Context, string) (bool, error) {
+	return r.future, r.err
+}
+
+func (r reservations) GetBusyApartmentIDs(context.Context, time.Time, time.Time) ([]string, error) {
+	return r.busy, r.err
+}
+
+func validDetails() ApartmentDetails {
+	return ApartmentDetails{Title: "Flat by the river", Address: "1 River street", City: "Lisbon", Price: 80, Capacity: 2}
+}
+
+func TestApartmentDetailsNormalize(t *testing.T) {
+	lat, lng, farAway := 38.7, -9.1, 181.0
+	tests := []struct {
+		name      string
+		edit      func(d *ApartmentDetails)
+		wantField string
+	}{
+		{name: "valid", edit: func(d *ApartmentDetails) {}},
+		{name: "short title", edit: func(d *ApartmentDetails) { d.Title = "  ab  " }, wantField: "title"},
+		{name: "long title", edit: func(d *ApartmentDetails) { d.Title = strings.Repeat("a", maxTitleLength+1) }, wantField: "title"},
+		{name: "blank address", edit: func(d *ApartmentDetails) { d.Address = " " }, wantField: "address"},
+		{name: "long address", edit: func(d *ApartmentDetails) { d.Address = strings.Repeat("a", maxAddressLength+1) }, wantField: "address"},
+		{name: "no city", edit: func(d *ApartmentDetails) { d.City = "" }, wantField: "city"},
+		{name: "unknown city", edit: func(d *ApartmentDetails) { d.City = "Atlantis" }, wantField: "city"},
+		{name: "long description", edit: func(d *ApartmentDetails) { d.Description = strings.Repeat("a", maxDescription+1) },
+			wantField: "description"},
+		{name: "free", edit: func(d *ApartmentDetails) { d.Price = 0 }, wantField: "price"},
+		{name: "too expensive", edit: func(d *ApartmentDetails) { d.Price = maxPrice + 1 }, wantField: "price"},
+		{name: "no guests", edit: func(d *ApartmentDetails) { d.Capacity = 0 }, wantField: "capacity"},
+		{name: "negative bedrooms", edit: func(d *ApartmentDetails) { d.Bedrooms = -1 }, wantField: "bedrooms"},
+		{name: "too many amenities", edit: func(d *ApartmentDetails) { d.Amenities = numbered("amenity", maxAmenities+1) },
+			wantField: "amenities"},
+		{name: "location", edit: func(d *ApartmentDetails) { d.Latitude, d.Longitude = &lat, &lng }},
+		{name: "latitude only", edit: func(d *ApartmentDetails) { d.Latitude = &lat }, wantField: "lat"},
+		{name: "latitude out of range", edit: func(d *ApartmentDetails) { d.Latitude, d.Longitude = &farAway, &lng }, wantField: "lat"},
+		{name: "longitude out of range", edit: func(d *ApartmentDetails) { d.Latitude, d.Longitude = &lat, &farAway }, wantField: "lng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := validDetails()
+			tt.edit(&details)
+			assertValidationField(t, details.Normalize(), tt.wantField)
+		})
+	}
+
+	details := validDetails()
+	details.Title, details.City, details.Amenities = " Flat by the river ", " Lisbon ", []string{" Wifi", "", "wifi", "Pool "}
+	if err := details.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if details.Title != "Flat by the river" || details.City != "Lisbon" || !reflect.DeepEqual(details.Amenities, []string{"wifi", "pool"}) {
+		t.Errorf("got %+v, want the details trimmed", details)
+	}
+}
+
+func TestApartmentDetailsCity(t *testing.T) {
+	details := validDetails()
+	details.City = " lisboa "
+	if err := details.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	var apartment Apartment
+	details.apply(&apartment)
+	if apartment.City != "Lisbon" || apartment.CityID != "lisbon" || apartment.Country != "PT" || apartment.TimeZone != "Europe/Lisbon" {
+		t.Errorf("got city %q (%s) in %s, %s", apartment.City, apartment.CityID, apartment.Country, apartment.TimeZone)
+	}
+
+	filter := ListFilter{City: "LISBOA"}
+	search := SearchFilter{City: " lisbon"}
+	if err := filter.Normalize(); err != nil || search.Normalize() != nil || filter.City != "Lisbon" || search.City != "Lisbon" {
+		t.Errorf("got listing of %q and search of %q, want the catalog name", filter.City, search.City)
+	}
+}
+
+func TestListFilterNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    ListFilter
+		wantField string
+		wantQuery string
+	}{
+		{name: "empty", filter: ListFilter{}},
+		{name: "query", filter: ListFilter{Query: "  river view "}, wantQuery: "river view"},
+		{name: "longest query", filter: ListFilter{Query: strings.Repeat("a", maxQueryLength)}, wantQuery: strings.Repeat("a", maxQueryLength)},
+		{name: "too long query", filter: ListFilter{Query: strings.Repeat("a", maxQueryLength+1)}, wantField: "q"},
+		{name: "negative limit", filter: ListFilter{Limit: -1}, wantField: "limit"},
+		{name: "negative offset", filter: ListFilter{Offset: -1}, wantField: "offset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			assertValidationField(t, filter.Normalize(), tt.wantField)
+			if tt.wantField == "" && (filter.Query != tt.wantQuery || filter.Limit != defaultSearchLimit) {
+				t.Errorf("got query %q and limit %d, want %q and %d", filter.Query, filter.Limit, tt.wantQuery, defaultSearchLimit)
+			}
+		})
+	}
+}
+
+func TestSearchFilterNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    SearchFilter
+		wantField string
+	}{
+		{name: "empty", filter: SearchFilter{}},
+		{name: "price range", filter: SearchFilter{MinPrice: 50, MaxPrice: 100}},
+		{name: "minimal price only", filter: SearchFilter{MinPrice: 50}},
+		{name: "negative minimal price", filter: SearchFilter{MinPrice: -1}, wantField: "minPrice"},
+		{name: "negative maximal price", filter: SearchFilter{MaxPrice: -1}, wantField: "maxPrice"},
+		{name: "inverted price range", filter: SearchFilter{MinPrice: 100, MaxPrice: 50}, wantField: "maxPrice"},
+		{name: "negative guests", filter: SearchFilter{Guests: -1}, wantField: "guests"},
+		{name: "negative bedrooms", filter: SearchFilter{MinBedrooms: -1}, wantField: "bedrooms"},
+		{name: "rating above the maximum", filter: SearchFilter{MinRating: maxRating + 1}, wantField: "minRating"},
+		{name: "negative offset", filter: SearchFilter{Offset: -1}, wantField: "offset"},
+		{name: "known sort", filter: SearchFilter{Sort: SortRating}},
+		{name: "unknown sort", filter: SearchFilter{Sort: "cheapest"}, wantField: "sort"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidationField(t, tt.filter.Normalize(), tt.wantField)
+		})
+	}
+
+	filter := SearchFilter{Limit: -5, Amenities: []string{"WIFI", " wifi "}}
+	if err := filter.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if filter.Limit != defaultSearchLimit || !reflect.DeepEqual(filter.Amenities, []string{"wifi"}) {
+		t.Errorf("got limit %d and amenities %v", filter.Limit, filter.Amenities)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var err error = &ValidationError{Field: "title", Reason: "is required"}
+	if got := err.Error(); got != "title: is required" {
+		t.Errorf("got %q", got)
+	}
+	assertValidationField(t, fmt.Errorf("creating apartment: %w", err), "title")
+}
+
+func TestUpdateApartmentOwnership(t *testing.T) {
+	apartment := Apartment{Owner: "owner", Title: "Old title"}
+	id := apartment.ID.Hex()
+	tests := []struct {
+		name    string
+		ownerID string
+		id      string
+		wantErr error
+	}{
+		{name: "owner", ownerID: "owner", id: id},
+		{name: "someone else", ownerID: "guest", id: id, wantErr: ErrNotApartmentOwner},
+		{name: "missing apartment", ownerID: "owner", id: "missing", wantErr: ErrApartmentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{apartments: map[string]Apartment{id: apartment}}
+			_, err := NewService(repository, nil, nil, nil).UpdateApartment(context.Background(), tt.ownerID, tt.id, validDetails())
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			wantTitle := apartment.Title
+			if err == nil {
+				wantTitle = validDetails().Title
+			}
+			if got := repository.apartments[id].Title; got != wantTitle {
+				t.Errorf("got title %q, want %q", got, wantTitle)
+			}
+		})
+	}
+}
+
+func TestUpdateApartmentReview(t *testing.T) {
+	tests := []struct {
+		status     ListingStatus
+		wantStatus ListingStatus
+	}{
+		{status: StatusDraft, wantStatus: StatusDraft},
+		{status: StatusPendingReview, wantStatus: StatusPendingReview},
+		{status: StatusPublished, wantStatus: StatusPendingReview},
+		{status: StatusSuspended, wantStatus: StatusSuspended},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			apartment := Apartment{ID: primitive.NewObjectID(), Owner: "owner", Status: tt.status}
+			id := apartment.ID.Hex()
+			repository := &memoryRepository{apartments: map[string]Apartment{id: apartment}}
+			updated, err := NewService(repository, nil, nil, nil).UpdateApartment(context.Background(), "owner", id, validDetails())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved := repository.apartments[id]; updated.Status != tt.wantStatus || saved.Status != tt.wantStatus {
+				t.Errorf("got status %q, saved %q, want %q", updated.Status, saved.Status, tt.wantStatus)
+			}
+			if saved := repository.apartments[id]; saved.Title != validDetails().Title {
+				t.Errorf("got title %q saved", saved.Title)
+			}
+			if len(repository.statuses) != 0 {
+				t.Errorf("got separate status updates %v, want the status saved with the details", repository.statuses)
+			}
+		})
+	}
+}
+
+func TestDeleteApartment(t *testing.T) {
+	apartment := Apartment{Owner: "owner", Photos: []Photo{{ID: "p", Keys: []string{"apartments/a/p/original.jpg"}}}}
+	id := apartment.ID.Hex()
+	tests := []struct {
+		name         string
+		ownerID      string
+		reservations reservations
+		wantErr      error
+	}{
+		{name: "no reservations", ownerID: "owner"},
+		{name: "someone else", ownerID: "guest", wantErr: ErrNotApartmentOwner},
+		{name: "future reservations", ownerID: "owner", reservations: reservations{future: true}, wantErr: ErrApartmentHasReservations},
+		{name: "booking down", ownerID: "owner", reservations: reservations{err: errors.New("timeout")}, wantErr: ErrCouldNotCheckReservations},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{apartments: map[string]Apartment{id: apartment}}
+			blobs := memoryBlobs{"apartments/a/p/original.jpg": nil}
+			err := NewService(repository, tt.reservations, blobs, nil).DeleteApartment(context.Background(), tt.ownerID, id)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if deleted := len(repository.deleted) == 1; deleted != (err == nil) {
+				t.Errorf("deleted %v", repository.deleted)
+			}
+			if kept := len(blobs) == 1; kept != (err != nil) {
+				t.Errorf("got blobs %v after deleting the apartment: %v", blobs, err)
+			}
+		})
+	}
+}
+
+func numbered(prefix string, n int) []string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprint(prefix, i)
+	}
+	return values
+}
+
+func TestSearchApartmentsAvailability(t *testing.T) {
+	checkIn := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	stay := SearchFilter{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3)}
+	busyID := primitive.NewObjectID()
+	tests := []struct {
+		name         string
+		filter       SearchFilter
+		reservations reservations
+		wantExcluded []primitive.ObjectID
+		wantDegraded bool
+		wantErr      error
+	}{
+		{name: "no stay dates"},
+		{name: "busy apartments", filter: stay, reservations: reservations{busy: []string{busyID.Hex(), "legacy"}},
+			wantExcluded: []primitive.ObjectID{busyID}},
+		{name: "booking timeout", filter: stay, reservations: reservations{err: nats.ErrTimeout}, wantDegraded: true},
+		{name: "booking deadline", filter: stay, reservations: reservations{err: context.DeadlineExceeded}, wantDegraded: true},
+		{name: "booking not running", filter: stay, reservations: reservations{err: nats.ErrNoResponders}, wantDegraded: true},
+		{name: "booking unavailable", filter: stay, reservations: reservations{err: &contracts.ReplyError{Code: contracts.CodeUnavailable}},
+			wantDegraded: true},
+		{name: "booking refusal", filter: stay, reservations: reservations{err: &contracts.ReplyError{Code: contracts.CodeInvalidArgument}},
+			wantErr: ErrCouldNotCheckReservations},
+		{name: "booking failure", filter: stay, reservations: reservations{err: &contracts.ReplyError{Code: contracts.CodeInternal}},
+			wantErr: ErrCouldNotCheckReservations},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{}
+			result, err := NewService(repository, tt.reservations, nil, nil).SearchApartments(context.Background(), tt.filter)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.AvailabilityDegraded != tt.wantDegraded || len(repository.searched.excludedIDs) != len(tt.wantExcluded) {
+				t.Fatalf("got degraded %v excluding %v", result.AvailabilityDegraded, repository.searched.excludedIDs)
+			}
+			for i, id := range tt.wantExcluded {
+				if repository.searched.excludedIDs[i] != id {
+					t.Errorf("got excluded %v, want %v", repository.searched.excludedIDs, tt.wantExcluded)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchFilterStayDates(t *testing.T) {
+	checkIn := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		filter    SearchFilter
+		wantField string
+	}{
+		{name: "stay", filter: SearchFilter{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 1)}},
+		{name: "check in only", filter: SearchFilter{CheckIn: checkIn}, wantField: "checkIn"},
+		{name: "check out only", filter: SearchFilter{CheckOut: checkIn}, wantField: "checkIn"},
+		{name: "same day", filter: SearchFilter{CheckIn: checkIn, CheckOut: checkIn}, wantField: "checkOut"},
+		{name: "widest window", filter: SearchFilter{CheckIn: checkIn, CheckOut: checkIn.Add(contracts.MaxBusyApartmentsWindow)}},
+		{
+			name:      "too wide window",
+			filter:    SearchFilter{CheckIn: checkIn, CheckOut: checkIn.Add(contracts.MaxBusyApartmentsWindow + time.Second)},
+			wantField: "checkOut",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidationField(t, tt.filter.Normalize(), tt.wantField)
+		})
+	}
+}
+
+func TestApartmentDetailsLocation(t *testing.T) {
+	lat, lng := 38.7, -9.1
+	apartment := Apartment{Location: NewGeoPoint(1, 2)}
+	details := validDetails()
+	details.apply(&apartment)
+	if apartment.Location != nil {
+		t.Errorf("got location %v after removing it", apartment.Location)
+	}
+	details.Latitude, details.Longitude = &lat, &lng
+	details.apply(&apartment)
+	if apartment.Location == nil || !reflect.DeepEqual(apartment.Location.Coordinates, []float64{lng, lat}) {
+		t.Errorf("got location %v, want longitude then latitude", apartment.Location)
+	}
+}
+
+func TestNearQueryNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      NearQuery
+		wantField  string
+		wantRadius float64
+	}{
+		{name: "default radius", query: NearQuery{Latitude: 38.7, Longitude: -9.1}, wantRadius: defaultNearRadius},
+		{name: "radius", query: NearQuery{Latitude: 38.7, Longitude: -9.1, Radius: 300}, wantRadius: 300},
+		{name: "poles and antimeridian", query: NearQuery{Latitude: -90, Longitude: 180}, wantRadius: defaultNearRadius},
+		{name: "latitude out of range", query: NearQuery{Latitude: 90.5}, wantField: "lat"},
+		{name: "longitude out of range", query: NearQuery{Longitude: -180.5}, wantField: "lng"},
+		{name: "negative radius", query: NearQuery{Radius: -1}, wantField: "radius"},
+		{name: "too wide radius", query: NearQuery{Radius: maxNearRadius + 1}, wantField: "radius"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			assertValidationField(t, query.Normalize(), tt.wantField)
+			if tt.wantField == "" && (query.Radius != tt.wantRadius || query.Limit != defaultSearchLimit) {
+				t.Errorf("got radius %v and limit %d, want %v and %d", query.Radius, query.Limit, tt.wantRadius, defaultSearchLimit)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		box       BoundingBox
+		wantField string
+	}{
+		{name: "viewport", box: BoundingBox{MinLatitude: 38.6, MinLongitude: -9.3, MaxLatitude: 38.8, MaxLongitude: -9}},
+		{name: "latitude out of range", box: BoundingBox{MinLatitude: -91, MaxLatitude: 1, MaxLongitude: 1}, wantField: "minLat"},
+		{name: "longitude out of range", box: BoundingBox{MaxLatitude: 1, MaxLongitude: 181}, wantField: "minLng"},
+		{name: "empty latitudes", box: BoundingBox{MinLatitude: 1, MaxLatitude: 1, MaxLongitude: 1}, wantField: "maxLat"},
+		{name: "inverted longitudes", box: BoundingBox{MaxLatitude: 1, MinLongitude: 2, MaxLongitude: 1}, wantField: "maxLng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := tt.box
+			assertValidationField(t, box.Normalize(), tt.wantField)
+		})
+	}
+
+	box := BoundingBox{MinLatitude: 10, MinLongitude: 20, MaxLatitude: 30, MaxLongitude: 60}
+	if center := box.Center(); !reflect.DeepEqual(center.Coordinates, []float64{40, 20}) {
+		t.Errorf("got center %v, want [40 20] as longitude and latitude", center.Coordinates)
+	}
+}
+
+// assertValidationField checks that err is a *ValidationError of field, or no error when field is empty.
+func assertValidationField(t *testing.T, err error, field string) {
+	t.Helper()
+	var validationErr *ValidationError
+	switch {
+	case field == "" && err != nil:
+		t.Errorf("got error %v, want none", err)
+	case field != "" && !errors.As(err, &validationErr):
+		t.Errorf("got error %v, want a validation error of %s", err, field)
+	case field != "" && validationErr.Field != field:
+		t.Errorf("got a validation error of %s, want %s", validationErr.Field, field)
+	}
+}
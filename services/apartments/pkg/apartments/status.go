@@ -0,0 +1,102 @@
+package apartments
+
+import (
+	"context"
+	"errors"
+)
+
+// ListingStatus is the publication state of an apartment. Only published apartments are shown to guests.
+type ListingStatus string
+
+const (
+	StatusDraft         ListingStatus = "draft"
+	StatusPendingReview ListingStatus = "pending_review"
+	StatusPublished     ListingStatus = "published"
+	StatusSuspended     ListingStatus = "suspended"
+	StatusArchived      ListingStatus = "archived"
+)
+
+var ErrInvalidStatusTransition = errors.New("apartment status can not be changed this way")
+var ErrAdminOnly = errors.New("only admins can do that")
+
+type statusTransition struct {
+	from ListingStatus
+	to   ListingStatus
+}
+
+// ownerTransitions are the status changes an owner can make to their own listing.
+var ownerTransitions = map[statusTransition]bool{
+	{StatusDraft, StatusPendingReview}:    true,
+	{StatusPendingReview, StatusDraft}:    true,
+	{StatusPublished, StatusDraft}:        true,
+	{StatusDraft, StatusArchived}:         true,
+	{StatusPublished, StatusArchived}:     true,
+	{StatusSuspended, StatusArchived}:     true,
+	{StatusArchived, StatusDraft}:         true,
+	{StatusPendingReview, StatusArchived}: true,
+}
+
+// adminTransitions are the moderation decisions an admin can make on any listing.
+var adminTransitions = map[statusTransition]bool{
+	{StatusPendingReview, StatusPublished}: true,
+	{StatusPendingReview, StatusDraft}:     true,
+	{StatusPublished, StatusSuspended}:     true,
+	{StatusSuspended, StatusPublished}:     true,
+}
+
+func (s ListingStatus) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPendingReview, StatusPublished, StatusSuspended, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// ChangeApartmentStatus moves an apartment to status if the actor is allowed to: owners manage their own drafts
+// and archive, admins moderate.
+func (s *service) ChangeApartmentStatus(ctx context.Context, actorID string, isAdmin bool, apartmentID string, status ListingStatus) (*Apartment, error) { //nolint:lll
+	if !status.Valid() {
+		return nil, &ValidationError{Field: "status", Reason: "must be one of draft, pending_review, published, suspended, archived"}
+	}
+	apartment, err := s.ar.GetApartmentByID(ctx, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	transition := statusTransition{from: apartment.Status, to: status}
+	isOwner := apartment.Owner == actorID
+	switch {
+	case isAdmin && adminTransitions[transition]:
+	case isOwner && ownerTransitions[transition]:
+	case !isOwner && !isAdmin:
+		return nil, ErrNotApartmentOwner
+	case !isAdmin && adminTransitions[transition]:
+		return nil, ErrAdminOnly
+	default:
+		return nil, ErrInvalidStatusTransition
+	}
+
+	if err = s.ar.UpdateStatus(ctx, apartmentID, apartment.Status, status); err != nil {
+		return nil, err
+	}
+	apartment.Status = status
+	return apartment, nil
+}
+
+func (s *service) GetOwnerApartments(ctx context.Context, ownerID string) ([]Apartment, error) {
+	return s.ar.GetApartmentsByOwner(ctx, ownerID)
+}
+
+// GetApartmentsForModeration lists apartments in the given status, pending review by default, to admins.
+func (s *service) GetApartmentsForModeration(ctx context.Context, isAdmin bool, status ListingStatus, limit, offset int) ([]Apartment, error) { //nolint:lll
+	if !isAdmin {
+		return nil, ErrAdminOnly
+	}
+	if status == "" {
+		status = StatusPendingReview
+	}
+	if !status.Valid() {
+		return nil, &ValidationError{Field: "status", Reason: "must be one of draft, pending_review, published, suspended, archived"}
+	}
+	return s.ar.GetApartmentsByStatus(ctx, status, limit, offset)
+}
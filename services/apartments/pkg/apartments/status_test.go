@@ -0,0 +1,122 @@
+package apartments
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestChangeApartmentStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID string
+		isAdmin bool
+		from    ListingStatus
+		to      ListingStatus
+		wantErr error
+	}{
+		{name: "owner submits a draft", actorID: "owner", from: StatusDraft, to: StatusPendingReview},
+		{name: "owner withdraws from review", actorID: "owner", from: StatusPendingReview, to: StatusDraft},
+		{name: "owner unpublishes", actorID: "owner", from: StatusPublished, to: StatusDraft},
+		{name: "owner archives", actorID: "owner", from: StatusPublished, to: StatusArchived},
+		{name: "owner archives a suspended listing", actorID: "owner", from: StatusSuspended, to: StatusArchived},
+		{name: "owner restores an archived listing", actorID: "owner", from: StatusArchived, to: StatusDraft},
+		{name: "admin publishes", actorID: "admin", isAdmin: true, from: StatusPendingReview, to: StatusPublished},
+		{name: "admin rejects", actorID: "admin", isAdmin: true, from: StatusPendingReview, to: StatusDraft},
+		{name: "admin suspends", actorID: "admin", isAdmin: true, from: StatusPublished, to: StatusSuspended},
+		{name: "admin reinstates", actorID: "admin", isAdmin: true, from: StatusSuspended, to: StatusPublished},
+		{name: "owning admin archives", actorID: "owner", isAdmin: true, from: StatusDraft, to: StatusArchived},
+
+		{name: "owner publishes", actorID: "owner", from: StatusPendingReview, to: StatusPublished, wantErr: ErrAdminOnly},
+		{name: "owner reinstates", actorID: "owner", from: StatusSuspended, to: StatusPublished, wantErr: ErrAdminOnly},
+		{name: "owner skips review", actorID: "owner", from: StatusDraft, to: StatusPublished, wantErr: ErrInvalidStatusTransition},
+		{name: "owner lifts a suspension", actorID: "owner", from: StatusSuspended, to: StatusDraft, wantErr: ErrInvalidStatusTransition},
+		{name: "owner keeps the status", actorID: "owner", from: StatusDraft, to: StatusDraft, wantErr: ErrInvalidStatusTransition},
+		{name: "stranger archives", actorID: "stranger", from: StatusPublished, to: StatusArchived, wantErr: ErrNotApartmentOwner},
+		{name: "admin archives", actorID: "admin", isAdmin: true, from: StatusPublished, to: StatusArchived,
+			wantErr: ErrInvalidStatusTransition},
+		{name: "admin publishes an archived listing", actorID: "admin", isAdmin: true, from: StatusArchived, to: StatusPublished,
+			wantErr: ErrInvalidStatusTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{apartments: map[string]Apartment{"id": {Owner: "owner", Status: tt.from}}}
+			apartment, err := NewService(repository, nil, nil, nil).ChangeApartmentStatus(context.Background(), tt.actorID, tt.isAdmin, "id", tt.to)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			wantStatuses := []ListingStatus{tt.to}
+			if err != nil {
+				wantStatuses = nil
+			} else if apartment.Status != tt.to {
+				t.Errorf("got status %q, want %q", apartment.Status, tt.to)
+			}
+			if !reflect.DeepEqual(repository.statuses, wantStatuses) {
+				t.Errorf("got status changes %v, want %v", repository.statuses, wantStatuses)
+			}
+		})
+	}
+
+	repository := &memoryRepository{apartments: map[string]Apartment{"id": {Owner: "owner", Status: StatusDraft}}}
+	_, err := NewService(repository, nil, nil, nil).ChangeApartmentStatus(context.Background(), "owner", false, "id", "deleted")
+	assertValidationField(t, err, "status")
+}
+
+func TestGetApartmentByIDVisibility(t *testing.T) {
+	tests := []struct {
+		status   ListingStatus
+		viewerID string
+		wantErr  error
+	}{
+		{status: StatusPublished},
+		{status: StatusPublished, viewerID: "guest"},
+		{status: StatusDraft, viewerID: "owner"},
+		{status: StatusSuspended, viewerID: "owner"},
+		{status: StatusDraft, wantErr: ErrApartmentNotFound},
+		{status: StatusPendingReview, viewerID: "guest", wantErr: ErrApartmentNotFound},
+		{status: StatusArchived, viewerID: "guest", wantErr: ErrApartmentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status)+" to "+tt.viewerID, func(t *testing.T) {
+			repository := &memoryRepository{apartments: map[string]Apartment{"id": {Owner: "owner", Status: tt.status}}}
+			if _, err := NewService(repository, nil, nil, nil).GetApartmentByID(context.Background(), tt.viewerID, "id"); err != tt.wantErr {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetApartmentsForModeration(t *testing.T) {
+	repository := &memoryRepository{apartments: map[string]Apartment{
+		"draft":     {Status: StatusDraft},
+		"pending":   {Status: StatusPendingReview},
+		"suspended": {Status: StatusSuspended},
+	}}
+	s := NewService(repository, nil, nil, nil)
+	tests := []struct {
+		name       string
+		isAdmin    bool
+		status     ListingStatus
+		wantErr    error
+		wantField  string
+		wantStatus ListingStatus
+	}{
+		{name: "pending review by default", isAdmin: true, wantStatus: StatusPendingReview},
+		{name: "suspended", isAdmin: true, status: StatusSuspended, wantStatus: StatusSuspended},
+		{name: "unknown status", isAdmin: true, status: "deleted", wantField: "status"},
+		{name: "not an admin", wantErr: ErrAdminOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apartments, err := s.GetApartmentsForModeration(context.Background(), tt.isAdmin, tt.status, 10, 0)
+			switch {
+			case tt.wantField != "":
+				assertValidationField(t, err, tt.wantField)
+			case err != tt.wantErr:
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			case err == nil && (len(apartments) != 1 || apartments[0].Status != tt.wantStatus):
+				t.Errorf("got %v, want the apartment in %q", apartments, tt.wantStatus)
+			}
+		})
+	}
+}
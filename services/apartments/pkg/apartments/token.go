@@ -0,0 +1,10 @@
+package apartments
+
+import (
+	"contracts/pkg/auth"
+)
+
+var ErrUnauthorized = auth.ErrUnauthorized
+
+// UserClaim is the user of a request, decoded by the auth.Verifier MakeHTTPHandler is given.
+type UserClaim = auth.UserClaim
@@ -3,6 +3,17 @@ package apartments
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"contracts/pkg/auth"
+	"contracts/pkg/contracts"
+	nats_tracing "contracts/pkg/nats-tracing"
+	"telemetry/pkg/telemetry"
 
 	"github.com/go-kit/kit/circuitbreaker"
 	kitlog "github.com/go-kit/kit/log"
@@ -11,41 +22,547 @@ import (
 	kitnats "github.com/go-kit/kit/transport/nats"
 	"github.com/gorilla/mux"
 	"github.com/nats-io/nats.go"
-	"github.com/openzipkin/zipkin-go"
-	"github.com/sergey-suslov/go-kit-nats-zipkin-tracing/natszipkin"
 	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/trace"
 
 	"net/http"
 )
 
 const queueName = "apartments"
-const getApartmentByIDSubject = "apartments.getApartmentById"
 
-func MakeHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+// legacyGetApartmentByIDSubject is contracts.SubjectGetApartmentByID before the messages were versioned, it is
+// served until no service requests it anymore.
+const legacyGetApartmentByIDSubject = "apartments.getApartmentById"
+
+// multipartOverhead is the room left for multipart headers and boundaries on top of MaxPhotoSize.
+const multipartOverhead = 1 << 20
+
+// MakeHTTPHandler serves the service over HTTP, every request is traced by a server span named after its route.
+// The users of requests are decoded from their bearer tokens by users.
+func MakeHTTPHandler(s Service, logger kitlog.Logger, tracer trace.TracerProvider, m EndpointMetrics, users *auth.Verifier) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		kithttp.ServerErrorEncoder(encodeError),
+		telemetry.HTTPServerTrace(tracer),
 	}
 
 	endpoint := makeGetApartmentsEndpoint(s)
 	endpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(endpoint)
+	endpoint = InstrumentingMiddleware(m, "GetApartments", TransportHTTP)(endpoint)
 	getApartmentsHandler := kithttp.NewServer(endpoint, decodeGetApartmentsRequest, encodeResponse, opts...)
 
+	searchEndpoint := makeSearchApartmentsEndpoint(s)
+	searchEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(searchEndpoint)
+	searchEndpoint = InstrumentingMiddleware(m, "SearchApartments", TransportHTTP)(searchEndpoint)
+	searchApartmentsHandler := kithttp.NewServer(searchEndpoint, decodeSearchApartmentsRequest, encodeResponse, opts...)
+
+	nearEndpoint := makeGetApartmentsNearEndpoint(s)
+	nearEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(nearEndpoint)
+	nearEndpoint = InstrumentingMiddleware(m, "GetApartmentsNear", TransportHTTP)(nearEndpoint)
+	getApartmentsNearHandler := kithttp.NewServer(nearEndpoint, decodeGetApartmentsNearRequest, encodeResponse, opts...)
+
+	withinEndpoint := makeGetApartmentsWithinEndpoint(s)
+	withinEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(withinEndpoint)
+	withinEndpoint = InstrumentingMiddleware(m, "GetApartmentsWithin", TransportHTTP)(withinEndpoint)
+	getApartmentsWithinHandler := kithttp.NewServer(withinEndpoint, decodeGetApartmentsWithinRequest, encodeResponse, opts...)
+
+	createEndpoint := makeCreateApartmentEndpoint(s)
+	createEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(createEndpoint)
+	createEndpoint = InstrumentingMiddleware(m, "CreateApartment", TransportHTTP)(createEndpoint)
+	createApartmentHandler := kithttp.NewServer(
+		createEndpoint,
+		DefaultRequestDecoder(decodeCreateApartmentRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	updateEndpoint := makeUpdateApartmentEndpoint(s)
+	updateEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(updateEndpoint)
+	updateEndpoint = InstrumentingMiddleware(m, "UpdateApartment", TransportHTTP)(updateEndpoint)
+	updateApartmentHandler := kithttp.NewServer(
+		updateEndpoint,
+		DefaultRequestDecoder(decodeUpdateApartmentRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	deleteEndpoint := makeDeleteApartmentEndpoint(s)
+	deleteEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(deleteEndpoint)
+	deleteEndpoint = InstrumentingMiddleware(m, "DeleteApartment", TransportHTTP)(deleteEndpoint)
+	deleteApartmentHandler := kithttp.NewServer(
+		deleteEndpoint,
+		DefaultRequestDecoder(decodeDeleteApartmentRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	addPhotoEndpoint := makeAddPhotoEndpoint(s)
+	addPhotoEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(addPhotoEndpoint)
+	addPhotoEndpoint = InstrumentingMiddleware(m, "AddPhoto", TransportHTTP)(addPhotoEndpoint)
+	addPhotoHandler := kithttp.NewServer(addPhotoEndpoint, DefaultRequestDecoder(decodeAddPhotoRequest), encodeResponse, opts...)
+
+	deletePhotoEndpoint := makeDeletePhotoEndpoint(s)
+	deletePhotoEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(deletePhotoEndpoint)
+	deletePhotoEndpoint = InstrumentingMiddleware(m, "DeletePhoto", TransportHTTP)(deletePhotoEndpoint)
+	deletePhotoHandler := kithttp.NewServer(deletePhotoEndpoint, DefaultRequestDecoder(decodePhotoRequest), encodeResponse, opts...)
+
+	reorderPhotosEndpoint := makeReorderPhotosEndpoint(s)
+	reorderPhotosEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(reorderPhotosEndpoint)
+	reorderPhotosEndpoint = InstrumentingMiddleware(m, "ReorderPhotos", TransportHTTP)(reorderPhotosEndpoint)
+	reorderPhotosHandler := kithttp.NewServer(
+		reorderPhotosEndpoint,
+		DefaultRequestDecoder(decodeReorderPhotosRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	setCoverPhotoEndpoint := makeSetCoverPhotoEndpoint(s)
+	setCoverPhotoEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(setCoverPhotoEndpoint)
+	setCoverPhotoEndpoint = InstrumentingMiddleware(m, "SetCoverPhoto", TransportHTTP)(setCoverPhotoEndpoint)
+	setCoverPhotoHandler := kithttp.NewServer(setCoverPhotoEndpoint, DefaultRequestDecoder(decodePhotoRequest), encodeResponse, opts...)
+
+	amenitiesEndpoint := InstrumentingMiddleware(m, "GetAmenities", TransportHTTP)(makeGetAmenitiesEndpoint(s))
+	getAmenitiesHandler := kithttp.NewServer(amenitiesEndpoint, decodeGetAmenitiesRequest, encodeResponse, opts...)
+	citiesEndpoint := InstrumentingMiddleware(m, "GetCities", TransportHTTP)(makeGetCitiesEndpoint(s))
+	getCitiesHandler := kithttp.NewServer(citiesEndpoint, decodeGetCitiesRequest, encodeResponse, opts...)
+
+	changeStatusEndpoint := makeChangeApartmentStatusEndpoint(s)
+	changeStatusEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(changeStatusEndpoint)
+	changeStatusEndpoint = InstrumentingMiddleware(m, "ChangeApartmentStatus", TransportHTTP)(changeStatusEndpoint)
+	changeStatusHandler := kithttp.NewServer(changeStatusEndpoint, DefaultRequestDecoder(decodeChangeStatusRequest), encodeResponse, opts...)
+
+	ownerApartmentsEndpoint := makeGetOwnerApartmentsEndpoint(s)
+	ownerApartmentsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(ownerApartmentsEndpoint)
+	ownerApartmentsEndpoint = InstrumentingMiddleware(m, "GetOwnerApartments", TransportHTTP)(ownerApartmentsEndpoint)
+	ownerApartmentsHandler := kithttp.NewServer(
+		ownerApartmentsEndpoint,
+		DefaultRequestDecoder(decodeUserApartmentsRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	moderationEndpoint := makeGetApartmentsForModerationEndpoint(s)
+	moderationEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(moderationEndpoint)
+	moderationEndpoint = InstrumentingMiddleware(m, "GetApartmentsForModeration", TransportHTTP)(moderationEndpoint)
+	moderationHandler := kithttp.NewServer(moderationEndpoint, DefaultRequestDecoder(decodeUserApartmentsRequest), encodeResponse, opts...)
+
+	getWishlistsEndpoint := makeGetWishlistsEndpoint(s)
+	getWishlistsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getWishlistsEndpoint)
+	getWishlistsEndpoint = InstrumentingMiddleware(m, "GetWishlists", TransportHTTP)(getWishlistsEndpoint)
+	getWishlistsHandler := kithttp.NewServer(getWishlistsEndpoint, DefaultRequestDecoder(decodeWishlistRequest), encodeResponse, opts...)
+
+	createWishlistEndpoint := makeCreateWishlistEndpoint(s)
+	createWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(createWishlistEndpoint)
+	createWishlistEndpoint = InstrumentingMiddleware(m, "CreateWishlist", TransportHTTP)(createWishlistEndpoint)
+	createWishlistHandler := kithttp.NewServer(
+		createWishlistEndpoint,
+		DefaultRequestDecoder(decodeCreateWishlistRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	getWishlistEndpoint := makeGetWishlistEndpoint(s)
+	getWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getWishlistEndpoint)
+	getWishlistEndpoint = InstrumentingMiddleware(m, "GetWishlist", TransportHTTP)(getWishlistEndpoint)
+	getWishlistHandler := kithttp.NewServer(getWishlistEndpoint, DefaultRequestDecoder(decodeWishlistRequest), encodeResponse, opts...)
+
+	deleteWishlistEndpoint := makeDeleteWishlistEndpoint(s)
+	deleteWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(deleteWishlistEndpoint)
+	deleteWishlistEndpoint = InstrumentingMiddleware(m, "DeleteWishlist", TransportHTTP)(deleteWishlistEndpoint)
+	deleteWishlistHandler := kithttp.NewServer(deleteWishlistEndpoint, DefaultRequestDecoder(decodeWishlistRequest), encodeResponse, opts...)
+
+	addToWishlistEndpoint := makeAddToWishlistEndpoint(s)
+	addToWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(addToWishlistEndpoint)
+	addToWishlistEndpoint = InstrumentingMiddleware(m, "AddToWishlist", TransportHTTP)(addToWishlistEndpoint)
+	addToWishlistHandler := kithttp.NewServer(addToWishlistEndpoint, DefaultRequestDecoder(decodeWishlistRequest), encodeResponse, opts...)
+
+	removeFromWishlistEndpoint := makeRemoveFromWishlistEndpoint(s)
+	removeFromWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(removeFromWishlistEndpoint)
+	removeFromWishlistEndpoint = InstrumentingMiddleware(m, "RemoveFromWishlist", TransportHTTP)(removeFromWishlistEndpoint)
+	removeFromWishlistHandler := kithttp.NewServer(
+		removeFromWishlistEndpoint,
+		DefaultRequestDecoder(decodeWishlistRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	shareWishlistEndpoint := makeShareWishlistEndpoint(s, true)
+	shareWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(shareWishlistEndpoint)
+	shareWishlistEndpoint = InstrumentingMiddleware(m, "ShareWishlist", TransportHTTP)(shareWishlistEndpoint)
+	shareWishlistHandler := kithttp.NewServer(shareWishlistEndpoint, DefaultRequestDecoder(decodeWishlistRequest), encodeResponse, opts...)
+
+	unshareWishlistEndpoint := makeShareWishlistEndpoint(s, false)
+	unshareWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(unshareWishlistEndpoint)
+	unshareWishlistEndpoint = InstrumentingMiddleware(m, "UnshareWishlist", TransportHTTP)(unshareWishlistEndpoint)
+	unshareWishlistHandler := kithttp.NewServer(unshareWishlistEndpoint, DefaultRequestDecoder(decodeWishlistRequest), encodeResponse, opts...)
+
+	sharedWishlistEndpoint := makeGetSharedWishlistEndpoint(s)
+	sharedWishlistEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(sharedWishlistEndpoint)
+	sharedWishlistEndpoint = InstrumentingMiddleware(m, "GetSharedWishlist", TransportHTTP)(sharedWishlistEndpoint)
+	getSharedWishlistHandler := kithttp.NewServer(sharedWishlistEndpoint, decodeGetSharedWishlistRequest, encodeResponse, opts...)
+
 	r := mux.NewRouter()
 
 	r.Handle("/apartments", getApartmentsHandler).Methods("GET")
+	r.Handle("/apartments", createApartmentHandler).Methods("POST")
+	r.Handle("/apartments/search", searchApartmentsHandler).Methods("GET")
+	r.Handle("/apartments/near", getApartmentsNearHandler).Methods("GET")
+	r.Handle("/apartments/within", getApartmentsWithinHandler).Methods("GET")
+	r.Handle("/apartments/mine", ownerApartmentsHandler).Methods("GET")
+	r.Handle("/apartments/moderation", moderationHandler).Methods("GET")
+	r.Handle("/apartments/{id}/status", changeStatusHandler).Methods("POST")
+	r.Handle("/amenities", getAmenitiesHandler).Methods("GET")
+	r.Handle("/cities", getCitiesHandler).Methods("GET")
+	r.Handle("/apartments/{id}", updateApartmentHandler).Methods("PUT")
+	r.Handle("/apartments/{id}", deleteApartmentHandler).Methods("DELETE")
+	r.Handle("/apartments/{id}/photos", limitBody(addPhotoHandler, MaxPhotoSize+multipartOverhead)).Methods("POST")
+	r.Handle("/apartments/{id}/photos", reorderPhotosHandler).Methods("PUT")
+	r.Handle("/apartments/{id}/photos/{photoId}", deletePhotoHandler).Methods("DELETE")
+	r.Handle("/apartments/{id}/photos/{photoId}/cover", setCoverPhotoHandler).Methods("PUT")
+	r.Handle("/wishlists", getWishlistsHandler).Methods("GET")
+	r.Handle("/wishlists", createWishlistHandler).Methods("POST")
+	r.Handle("/wishlists/shared/{token}", getSharedWishlistHandler).Methods("GET")
+	r.Handle("/wishlists/{id}", getWishlistHandler).Methods("GET")
+	r.Handle("/wishlists/{id}", deleteWishlistHandler).Methods("DELETE")
+	r.Handle("/wishlists/{id}/apartments/{apartmentId}", addToWishlistHandler).Methods("PUT")
+	r.Handle("/wishlists/{id}/apartments/{apartmentId}", removeFromWishlistHandler).Methods("DELETE")
+	r.Handle("/wishlists/{id}/share", shareWishlistHandler).Methods("POST")
+	r.Handle("/wishlists/{id}/share", unshareWishlistHandler).Methods("DELETE")
 
-	return r
+	return users.Handler(r)
 }
 
+// decodeGetApartmentsRequest reads the listing parameters from the JSON body, if any,
+// and lets city, q, amenities, cursor, limit and offset query parameters override them.
 func decodeGetApartmentsRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var req getApartmentsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
 		return nil, err
 	}
+	viewerID, err := decodeViewerID(r)
+	if err != nil {
+		return nil, err
+	}
+	req.ViewerID = viewerID
+	q := r.URL.Query()
+	if city := q.Get("city"); city != "" {
+		req.City = City(city)
+	}
+	if query := q.Get("q"); query != "" {
+		req.Query = query
+	}
+	if amenities := q.Get("amenities"); amenities != "" {
+		req.Amenities = strings.Split(amenities, ",")
+	}
+	if cursor := q.Get("cursor"); cursor != "" {
+		req.Cursor = cursor
+	}
+	if q.Get("limit") != "" {
+		if req.Limit, err = queryInt(q, "limit"); err != nil {
+			return nil, err
+		}
+	}
+	if q.Get("offset") != "" {
+		if req.Offset, err = queryInt(q, "offset"); err != nil {
+			return nil, err
+		}
+	}
 	return req, nil
 }
 
+func decodeSearchApartmentsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	var (
+		req = searchApartmentsRequest{SearchFilter{
+			City: City(q.Get("city")),
+			Sort: SortOrder(q.Get("sort")),
+		}}
+		err error
+	)
+	if req.ViewerID, err = decodeViewerID(r); err != nil {
+		return nil, err
+	}
+	if req.MinPrice, err = queryFloat(q, "minPrice"); err != nil {
+		return nil, err
+	}
+	if req.MaxPrice, err = queryFloat(q, "maxPrice"); err != nil {
+		return nil, err
+	}
+	if req.MinRating, err = queryFloat(q, "minRating"); err != nil {
+		return nil, err
+	}
+	if req.Guests, err = queryInt(q, "guests"); err != nil {
+		return nil, err
+	}
+	if req.MinBedrooms, err = queryInt(q, "bedrooms"); err != nil {
+		return nil, err
+	}
+	if req.Limit, err = queryInt(q, "limit"); err != nil {
+		return nil, err
+	}
+	if req.Offset, err = queryInt(q, "offset"); err != nil {
+		return nil, err
+	}
+	if req.InstantBook, err = queryBool(q, "instantBook"); err != nil {
+		return nil, err
+	}
+	if req.CheckIn, err = queryTime(q, "checkIn"); err != nil {
+		return nil, err
+	}
+	if req.CheckOut, err = queryTime(q, "checkOut"); err != nil {
+		return nil, err
+	}
+	if amenities := q.Get("amenities"); amenities != "" {
+		req.Amenities = strings.Split(amenities, ",")
+	}
+	return req, nil
+}
+
+func decodeGetApartmentsNearRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	var (
+		req NearQuery
+		err error
+	)
+	if req.ViewerID, err = decodeViewerID(r); err != nil {
+		return nil, err
+	}
+	if req.Latitude, err = requiredQueryFloat(q, "lat"); err != nil {
+		return nil, err
+	}
+	if req.Longitude, err = requiredQueryFloat(q, "lng"); err != nil {
+		return nil, err
+	}
+	if req.Radius, err = queryFloat(q, "radius"); err != nil {
+		return nil, err
+	}
+	if req.Limit, err = queryInt(q, "limit"); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeGetApartmentsWithinRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	var (
+		req BoundingBox
+		err error
+	)
+	if req.ViewerID, err = decodeViewerID(r); err != nil {
+		return nil, err
+	}
+	if req.MinLatitude, err = requiredQueryFloat(q, "minLat"); err != nil {
+		return nil, err
+	}
+	if req.MinLongitude, err = requiredQueryFloat(q, "minLng"); err != nil {
+		return nil, err
+	}
+	if req.MaxLatitude, err = requiredQueryFloat(q, "maxLat"); err != nil {
+		return nil, err
+	}
+	if req.MaxLongitude, err = requiredQueryFloat(q, "maxLng"); err != nil {
+		return nil, err
+	}
+	if req.Limit, err = queryInt(q, "limit"); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// decodeGetAmenitiesRequest takes the language from the lang query parameter or the first Accept-Language tag.
+func decodeGetAmenitiesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	language := r.URL.Query().Get("lang")
+	if language == "" {
+		language = r.Header.Get("Accept-Language")
+		if i := strings.IndexAny(language, ",;-"); i >= 0 {
+			language = language[:i]
+		}
+	}
+	return getAmenitiesRequest{Language: language}, nil
+}
+
+// decodeGetCitiesRequest reads the prefix and limit query parameters of city autocomplete.
+func decodeGetCitiesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	limit, err := queryInt(q, "limit")
+	if err != nil {
+		return nil, err
+	}
+	return getCitiesRequest{Prefix: q.Get("prefix"), Limit: limit}, nil
+}
+
+// decodeViewerID returns the id of the authenticated user, or "" for anonymous requests.
+func decodeViewerID(r *http.Request) (string, error) {
+	userClaim, err := auth.OptionalUserFromRequest(r)
+	if err != nil || userClaim == nil {
+		return "", err
+	}
+	return userClaim.ID, nil
+}
+
+func requiredQueryFloat(q url.Values, key string) (float64, error) {
+	if q.Get(key) == "" {
+		return 0, &ValidationError{Field: key, Reason: "is required"}
+	}
+	return queryFloat(q, key)
+}
+
+func queryFloat(q url.Values, key string) (float64, error) {
+	value := q.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &ValidationError{Field: key, Reason: "must be a number"}
+	}
+	return f, nil
+}
+
+func queryInt(q url.Values, key string) (int, error) {
+	value := q.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ValidationError{Field: key, Reason: "must be an integer"}
+	}
+	return i, nil
+}
+
+func queryBool(q url.Values, key string) (bool, error) {
+	value := q.Get(key)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ValidationError{Field: key, Reason: "must be true or false"}
+	}
+	return b, nil
+}
+
+// queryTime accepts both RFC 3339 timestamps and plain 2006-01-02 dates.
+func queryTime(q url.Values, key string) (time.Time, error) {
+	value := q.Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, &ValidationError{Field: key, Reason: "must be a date"}
+	}
+	return t, nil
+}
+
+func decodeCreateApartmentRequest(r *http.Request) (UserClaimable, error) {
+	var req createApartmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func decodeUpdateApartmentRequest(r *http.Request) (UserClaimable, error) {
+	var req updateApartmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ApartmentID = mux.Vars(r)["id"]
+	return &req, nil
+}
+
+func decodeDeleteApartmentRequest(r *http.Request) (UserClaimable, error) {
+	return &deleteApartmentRequest{ApartmentID: mux.Vars(r)["id"]}, nil
+}
+
+// limitBody fails reading the body of requests to next after n bytes, the connection is closed once the request
+// is answered instead of reading the rest of the body.
+func limitBody(next http.Handler, n int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeAddPhotoRequest reads the image from the "photo" field of a multipart form.
+func decodeAddPhotoRequest(r *http.Request) (UserClaimable, error) {
+	if r.ContentLength > MaxPhotoSize+multipartOverhead {
+		return nil, ErrPhotoTooLarge
+	}
+	file, _, err := r.FormFile("photo")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, ErrPhotoTooLarge
+		}
+		return nil, &ValidationError{Field: "photo", Reason: "a multipart file of at most 10MB is required"}
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, MaxPhotoSize+1))
+	if err != nil {
+		return nil, err
+	}
+	return &addPhotoRequest{ApartmentID: mux.Vars(r)["id"], Data: data}, nil
+}
+
+func decodePhotoRequest(r *http.Request) (UserClaimable, error) {
+	vars := mux.Vars(r)
+	return &photoRequest{ApartmentID: vars["id"], PhotoID: vars["photoId"]}, nil
+}
+
+func decodeReorderPhotosRequest(r *http.Request) (UserClaimable, error) {
+	var req reorderPhotosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ApartmentID = mux.Vars(r)["id"]
+	return &req, nil
+}
+
+func decodeChangeStatusRequest(r *http.Request) (UserClaimable, error) {
+	var req changeStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ApartmentID = mux.Vars(r)["id"]
+	return &req, nil
+}
+
+func decodeUserApartmentsRequest(r *http.Request) (UserClaimable, error) {
+	q := r.URL.Query()
+	req := userApartmentsRequest{Status: ListingStatus(q.Get("status"))}
+	var err error
+	if req.Limit, err = queryInt(q, "limit"); err != nil {
+		return nil, err
+	}
+	if req.Offset, err = queryInt(q, "offset"); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func decodeCreateWishlistRequest(r *http.Request) (UserClaimable, error) {
+	var req wishlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func decodeWishlistRequest(r *http.Request) (UserClaimable, error) {
+	vars := mux.Vars(r)
+	return &wishlistRequest{WishlistID: vars["id"], ApartmentID: vars["apartmentId"]}, nil
+}
+
+func decodeGetSharedWishlistRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return getSharedWishlistRequest{Token: mux.Vars(r)["token"]}, nil
+}
+
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if e, ok := response.(Errorer); ok && e.Error() != nil {
 		encodeError(ctx, e.Error(), w)
@@ -57,35 +574,105 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	switch err {
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
-	}
+	w.WriteHeader(statusOf(err))
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
 		"error": err.Error(),
 	})
 }
 
-func MakeNatsHandler(s Service, nc *nats.Conn, tracer *zipkin.Tracer) {
-	apartmentByIDEndpoint := makeGetApartmentByIDEndpoint(s)
+// statusOf is the HTTP status code requests failing with err are answered with.
+func statusOf(err error) int {
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr), err == ErrWrongIDFormat:
+		return http.StatusBadRequest
+	case err == ErrUnauthorized:
+		return http.StatusUnauthorized
+	case err == ErrNotApartmentOwner, err == ErrAdminOnly:
+		return http.StatusForbidden
+	case err == ErrPhotoTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case err == ErrUnsupportedPhotoType:
+		return http.StatusUnsupportedMediaType
+	case err == ErrTooManyPhotos, err == ErrTooManyWishlists, err == ErrWishlistFull:
+		return http.StatusConflict
+	case err == ErrApartmentNotFound, err == ErrPhotoNotFound, err == ErrWishlistNotFound:
+		return http.StatusNotFound
+	case err == ErrApartmentHasReservations, err == ErrInvalidStatusTransition:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type UserClaimable interface {
+	SetUserClaim(claim *UserClaim)
+}
+
+func DefaultRequestDecoder(decoder func(r *http.Request) (UserClaimable, error)) func(_ context.Context, r *http.Request) (interface{}, error) { //nolint:lll
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		userClaim, err := auth.UserFromRequest(r)
+		if err != nil {
+			return nil, err
+		}
+
+		request, err := decoder(r)
+		if err != nil {
+			return nil, err
+		}
+		request.SetUserClaim(userClaim)
+		return request, nil
+	}
+}
+
+func MakeNatsHandler(s Service, nc *nats.Conn, tracer trace.TracerProvider, m EndpointMetrics, traceOptions ...nats_tracing.TracerOption) {
+	traceOption := nats_tracing.Options(traceOptions...)
+	apartmentByIDEndpoint := nats_tracing.TraceEndpointErrors(makeGetApartmentByIDEndpoint(s))
+	apartmentByIDEndpoint = InstrumentingMiddleware(m, "GetApartmentByID", TransportNATS)(apartmentByIDEndpoint)
 	subscriber := kitnats.NewSubscriber(
 		apartmentByIDEndpoint,
-		decodeGetApartmentByIDRequest,
+		contracts.DecodeGetApartmentByIDRequest,
+		contracts.EncodeResponse,
+		nats_tracing.NATSSubscriberTrace(tracer, traceOption, nats_tracing.SetName("get apartments by id")),
+		kitnats.SubscriberBefore(contracts.ContentTypeToContext),
+	)
+	_, err := nc.QueueSubscribe(contracts.SubjectGetApartmentByID, queueName, subscriber.ServeMsg(nc))
+	if err != nil {
+		panic(err)
+	}
+
+	// Services which predate the contracts still request the unversioned subject.
+	legacyEndpoint := InstrumentingMiddleware(m, "LegacyGetApartmentByID", TransportNATS)(makeLegacyGetApartmentByIDEndpoint(s))
+	legacySubscriber := kitnats.NewSubscriber(
+		legacyEndpoint,
+		contracts.DecodeGetApartmentByIDRequest,
 		kitnats.EncodeJSONResponse,
-		natszipkin.NATSSubscriberTrace(tracer, natszipkin.Name("get apartments by id")),
+		nats_tracing.NATSSubscriberTrace(tracer, traceOption, nats_tracing.SetName("get apartments by id")),
 	)
-	_, err := nc.QueueSubscribe(getApartmentByIDSubject, queueName, subscriber.ServeMsg(nc))
+	_, err = nc.QueueSubscribe(legacyGetApartmentByIDSubject, queueName, legacySubscriber.ServeMsg(nc))
 	if err != nil {
 		panic(err)
 	}
-}
 
-func decodeGetApartmentByIDRequest(_ context.Context, msg *nats.Msg) (request interface{}, err error) {
-	var getApartmentByIDRequest getApartmentByIDRequest
-	err = json.Unmarshal(msg.Data, &getApartmentByIDRequest)
+	apartmentsByOwnerEndpoint := nats_tracing.TraceEndpointErrors(makeGetApartmentsByOwnerEndpoint(s))
+	apartmentsByOwnerEndpoint = InstrumentingMiddleware(m, "GetApartmentsByOwner", TransportNATS)(apartmentsByOwnerEndpoint)
+	apartmentsByOwnerSubscriber := kitnats.NewSubscriber(
+		apartmentsByOwnerEndpoint,
+		contracts.DecodeGetApartmentsByOwnerRequest,
+		contracts.EncodeResponse,
+		nats_tracing.NATSSubscriberTrace(tracer, traceOption, nats_tracing.SetName("get apartments by owner")),
+		kitnats.SubscriberBefore(contracts.ContentTypeToContext),
+	)
+	_, err = nc.QueueSubscribe(contracts.SubjectGetApartmentsByOwner, queueName, apartmentsByOwnerSubscriber.ServeMsg(nc))
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
 
-	return getApartmentByIDRequest, nil
+	// Pings are answered right away, they are neither traced nor counted.
+	_, err = nc.QueueSubscribe(contracts.SubjectPingApartments, queueName, func(msg *nats.Msg) {
+		_ = msg.Respond(nil)
+	})
+	if err != nil {
+		panic(err)
+	}
 }
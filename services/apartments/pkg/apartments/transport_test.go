@@ -0,0 +1,46 @@
+package apartments
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeAddPhotoRequestSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		wantErr error
+	}{
+		{name: "small photo", size: 1 << 10},
+		{name: "too large body", size: MaxPhotoSize + multipartOverhead, wantErr: ErrPhotoTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("photo", "photo.jpg")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = part.Write(make([]byte, tt.size))
+			_ = form.Close()
+
+			// The reader hides the length of the body, as a chunked upload does.
+			r := httptest.NewRequest(http.MethodPost, "/apartments/a/photos", io.MultiReader(&body))
+			r.Header.Set("Content-Type", form.FormDataContentType())
+			var decodeErr error
+			handler := limitBody(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				_, decodeErr = decodeAddPhotoRequest(r)
+			}), MaxPhotoSize+multipartOverhead)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if !errors.Is(decodeErr, tt.wantErr) {
+				t.Errorf("got error %v, want %v", decodeErr, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package apartments
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const wishlistCollectionName = "wishlists"
+
+type MongoRepositoryWishlists struct {
+	db *mongo.Database
+}
+
+func NewWishlistRepository(db *mongo.Database) *MongoRepositoryWishlists {
+	return &MongoRepositoryWishlists{db: db}
+}
+
+// EnsureIndexes creates the indexes used by wishlist queries. It is safe to call on every start.
+func (r *MongoRepositoryWishlists) EnsureIndexes(ctx context.Context) error {
+	_, err := r.db.Collection(wishlistCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "created", Value: 1}},
+			Options: options.Index().SetName("owner_created"),
+		},
+		{
+			Keys: bson.D{{Key: "shareToken", Value: 1}},
+			Options: options.Index().
+				SetName("shareToken").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "shareToken", Value: bson.D{{Key: "$exists", Value: true}}}}),
+		},
+	})
+	return err
+}
+
+func (r *MongoRepositoryWishlists) CreateWishlist(ctx context.Context, wishlist *Wishlist) (*Wishlist, error) {
+	result, err := r.db.Collection(wishlistCollectionName).InsertOne(ctx, wishlist)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	wishlist.ID = result.InsertedID.(primitive.ObjectID)
+	return wishlist, nil
+}
+
+func (r *MongoRepositoryWishlists) GetWishlistsByOwner(ctx context.Context, ownerID string) ([]Wishlist, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created", Value: 1}})
+	cursor, err := r.db.Collection(wishlistCollectionName).Find(ctx, bson.D{{Key: "owner", Value: ownerID}}, opts)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	wishlists := make([]Wishlist, 0)
+	err = cursor.All(ctx, &wishlists)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	return wishlists, nil
+}
+
+func (r *MongoRepositoryWishlists) GetWishlistByID(ctx context.Context, wishlistID string) (*Wishlist, error) {
+	objectID, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return nil, ErrWrongIDFormat
+	}
+	return r.findWishlist(ctx, bson.D{{Key: "_id", Value: objectID}})
+}
+
+func (r *MongoRepositoryWishlists) GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error) {
+	if token == "" {
+		return nil, ErrWishlistNotFound
+	}
+	return r.findWishlist(ctx, bson.D{{Key: "shareToken", Value: token}})
+}
+
+func (r *MongoRepositoryWishlists) findWishlist(ctx context.Context, query bson.D) (*Wishlist, error) {
+	var wishlist Wishlist
+	err := r.db.Collection(wishlistCollectionName).FindOne(ctx, query).Decode(&wishlist)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWishlistNotFound
+	}
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	if wishlist.Items == nil {
+		wishlist.Items = []WishlistItem{}
+	}
+	return &wishlist, nil
+}
+
+func (r *MongoRepositoryWishlists) DeleteWishlist(ctx context.Context, wishlistID string) error {
+	objectID, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	result, err := r.db.Collection(wishlistCollectionName).DeleteOne(ctx, bson.D{{Key: "_id", Value: objectID}})
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.DeletedCount == 0 {
+		return ErrWishlistNotFound
+	}
+	return nil
+}
+
+// AddWishlistItem appends the item unless the wishlist already holds its apartment.
+func (r *MongoRepositoryWishlists) AddWishlistItem(ctx context.Context, wishlistID string, item WishlistItem) error {
+	objectID, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	_, err = r.db.Collection(wishlistCollectionName).UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: objectID}, {Key: "items.apartmentId", Value: bson.D{{Key: "$ne", Value: item.ApartmentID}}}},
+		bson.D{{Key: "$push", Value: bson.D{{Key: "items", Value: item}}}},
+	)
+	if err != nil {
+		return ErrDatabase
+	}
+	return nil
+}
+
+func (r *MongoRepositoryWishlists) RemoveWishlistItem(ctx context.Context, wishlistID, apartmentID string) error {
+	objectID, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	result, err := r.db.Collection(wishlistCollectionName).UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: objectID}},
+		bson.D{{Key: "$pull", Value: bson.D{{Key: "items", Value: bson.D{{Key: "apartmentId", Value: apartmentID}}}}}},
+	)
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.MatchedCount == 0 {
+		return ErrWishlistNotFound
+	}
+	return nil
+}
+
+// SetShareToken replaces the share token of the wishlist, an empty token stops sharing it.
+func (r *MongoRepositoryWishlists) SetShareToken(ctx context.Context, wishlistID, token string) error {
+	objectID, err := primitive.ObjectIDFromHex(wishlistID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "shareToken", Value: token}}}}
+	if token == "" {
+		update = bson.D{{Key: "$unset", Value: bson.D{{Key: "shareToken", Value: ""}}}}
+	}
+	result, err := r.db.Collection(wishlistCollectionName).UpdateOne(ctx, bson.D{{Key: "_id", Value: objectID}}, update)
+	if err != nil {
+		return ErrDatabase
+	}
+	if result.MatchedCount == 0 {
+		return ErrWishlistNotFound
+	}
+	return nil
+}
+
+func (r *MongoRepositoryWishlists) GetSavedApartmentIDs(ctx context.Context, ownerID string, apartmentIDs []string) ([]string, error) {
+	values, err := r.db.Collection(wishlistCollectionName).Distinct(
+		ctx,
+		"items.apartmentId",
+		bson.D{{Key: "owner", Value: ownerID}, {Key: "items.apartmentId", Value: bson.D{{Key: "$in", Value: apartmentIDs}}}},
+	)
+	if err != nil {
+		return nil, ErrDatabase
+	}
+	requested := make(map[string]bool, len(apartmentIDs))
+	for _, id := range apartmentIDs {
+		requested[id] = true
+	}
+	// Distinct returns every apartment of the matching wishlists, not only the requested ones.
+	saved := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok && requested[id] {
+			saved = append(saved, id)
+		}
+	}
+	return saved, nil
+}
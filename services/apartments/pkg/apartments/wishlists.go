@@ -0,0 +1,276 @@
+package apartments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxWishlistNameLength = 100
+	maxWishlistsPerUser   = 50
+	maxWishlistItems      = 500
+	shareTokenBytes       = 18
+)
+
+var ErrWishlistNotFound = errors.New("wishlist not found")
+var ErrTooManyWishlists = errors.New("user has too many wishlists")
+var ErrWishlistFull = errors.New("wishlist has too many apartments")
+
+// Wishlist is a named list of apartments saved by a guest. A wishlist with a ShareToken can be read by anyone
+// knowing the token.
+type Wishlist struct {
+	ID         primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	Owner      string             `json:"owner,omitempty"`
+	Name       string             `json:"name"`
+	Items      []WishlistItem     `json:"items"`
+	ShareToken string             `json:"shareToken,omitempty" bson:"shareToken,omitempty"`
+	Created    time.Time          `json:"created"`
+}
+
+type WishlistItem struct {
+	ApartmentID string    `json:"apartmentId" bson:"apartmentId"`
+	Added       time.Time `json:"added"`
+}
+
+// SavedApartment is a wishlist item with its apartment. Apartments which are not published anymore stay
+// in the list but are not Available, Apartment is nil for them unless the viewer owns them, and when the
+// apartment was deleted.
+type SavedApartment struct {
+	WishlistItem
+	Available bool       `json:"available"`
+	Apartment *Apartment `json:"apartment,omitempty"`
+}
+
+// WishlistView is a wishlist with the apartments it holds, in the order they were added.
+type WishlistView struct {
+	Wishlist
+	Apartments []SavedApartment `json:"apartments"`
+}
+
+type WishlistRepository interface {
+	CreateWishlist(ctx context.Context, wishlist *Wishlist) (*Wishlist, error)
+	GetWishlistsByOwner(ctx context.Context, ownerID string) ([]Wishlist, error)
+	GetWishlistByID(ctx context.Context, wishlistID string) (*Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error)
+	DeleteWishlist(ctx context.Context, wishlistID string) error
+	AddWishlistItem(ctx context.Context, wishlistID string, item WishlistItem) error
+	RemoveWishlistItem(ctx context.Context, wishlistID, apartmentID string) error
+	SetShareToken(ctx context.Context, wishlistID, token string) error
+	// GetSavedApartmentIDs returns which of apartmentIDs are in any wishlist of the owner.
+	GetSavedApartmentIDs(ctx context.Context, ownerID string, apartmentIDs []string) ([]string, error)
+}
+
+func (s *service) GetWishlists(ctx context.Context, userID string) ([]Wishlist, error) {
+	return s.wr.GetWishlistsByOwner(ctx, userID)
+}
+
+func (s *service) CreateWishlist(ctx context.Context, userID, name string) (*Wishlist, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return nil, &ValidationError{Field: "name", Reason: "is required"}
+	case len(name) > maxWishlistNameLength:
+		return nil, &ValidationError{Field: "name", Reason: fmt.Sprintf("must be at most %d characters long", maxWishlistNameLength)}
+	}
+	wishlists, err := s.wr.GetWishlistsByOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wishlists) >= maxWishlistsPerUser {
+		return nil, ErrTooManyWishlists
+	}
+	return s.wr.CreateWishlist(ctx, &Wishlist{Owner: userID, Name: name, Items: []WishlistItem{}, Created: time.Now()})
+}
+
+func (s *service) GetWishlist(ctx context.Context, userID, wishlistID string) (*WishlistView, error) {
+	wishlist, err := s.getOwnedWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return s.viewWishlist(ctx, userID, wishlist)
+}
+
+// GetSharedWishlist returns the wishlist shared with token, without its owner.
+func (s *service) GetSharedWishlist(ctx context.Context, token string) (*WishlistView, error) {
+	wishlist, err := s.wr.GetWishlistByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	wishlist.Owner = ""
+	return s.viewWishlist(ctx, "", wishlist)
+}
+
+func (s *service) DeleteWishlist(ctx context.Context, userID, wishlistID string) error {
+	if _, err := s.getOwnedWishlist(ctx, userID, wishlistID); err != nil {
+		return err
+	}
+	return s.wr.DeleteWishlist(ctx, wishlistID)
+}
+
+// AddToWishlist saves a published apartment to the wishlist, saving it again changes nothing.
+func (s *service) AddToWishlist(ctx context.Context, userID, wishlistID, apartmentID string) (*Wishlist, error) {
+	wishlist, err := s.getOwnedWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range wishlist.Items {
+		if item.ApartmentID == apartmentID {
+			return wishlist, nil
+		}
+	}
+	if len(wishlist.Items) >= maxWishlistItems {
+		return nil, ErrWishlistFull
+	}
+	apartment, err := s.ar.GetApartmentByID(ctx, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if apartment.Status != StatusPublished {
+		return nil, ErrApartmentNotFound
+	}
+
+	item := WishlistItem{ApartmentID: apartmentID, Added: time.Now()}
+	if err = s.wr.AddWishlistItem(ctx, wishlistID, item); err != nil {
+		return nil, err
+	}
+	wishlist.Items = append(wishlist.Items, item)
+	return wishlist, nil
+}
+
+func (s *service) RemoveFromWishlist(ctx context.Context, userID, wishlistID, apartmentID string) (*Wishlist, error) {
+	wishlist, err := s.getOwnedWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.wr.RemoveWishlistItem(ctx, wishlistID, apartmentID); err != nil {
+		return nil, err
+	}
+	items := make([]WishlistItem, 0, len(wishlist.Items))
+	for _, item := range wishlist.Items {
+		if item.ApartmentID != apartmentID {
+			items = append(items, item)
+		}
+	}
+	wishlist.Items = items
+	return wishlist, nil
+}
+
+// ShareWishlist gives the wishlist a new share token, or revokes it when shared is false. A new token
+// makes links shared before stop working.
+func (s *service) ShareWishlist(ctx context.Context, userID, wishlistID string, shared bool) (*Wishlist, error) {
+	wishlist, err := s.getOwnedWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	token := ""
+	if shared {
+		if token, err = newShareToken(); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.wr.SetShareToken(ctx, wishlistID, token); err != nil {
+		return nil, err
+	}
+	wishlist.ShareToken = token
+	return wishlist, nil
+}
+
+// getOwnedWishlist returns the wishlist if it belongs to userID. Wishlists of other users are reported as
+// not found, their existence is none of the caller's business.
+func (s *service) getOwnedWishlist(ctx context.Context, userID, wishlistID string) (*Wishlist, error) {
+	wishlist, err := s.wr.GetWishlistByID(ctx, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	if wishlist.Owner != userID {
+		return nil, ErrWishlistNotFound
+	}
+	return wishlist, nil
+}
+
+// viewWishlist loads the apartments of the wishlist as viewerID may see them, anonymous viewers of shared
+// wishlists are "".
+func (s *service) viewWishlist(ctx context.Context, viewerID string, wishlist *Wishlist) (*WishlistView, error) {
+	apartmentIDs := make([]string, 0, len(wishlist.Items))
+	for _, item := range wishlist.Items {
+		apartmentIDs = append(apartmentIDs, item.ApartmentID)
+	}
+	apartments, err := s.ar.GetApartmentsByIDs(ctx, apartmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Apartment, len(apartments))
+	for i := range apartments {
+		apartments[i].Saved = true
+		byID[apartments[i].ID.Hex()] = &apartments[i]
+	}
+
+	view := &WishlistView{Wishlist: *wishlist, Apartments: make([]SavedApartment, 0, len(wishlist.Items))}
+	for _, item := range wishlist.Items {
+		apartment := byID[item.ApartmentID]
+		available := apartment != nil && apartment.Status == StatusPublished
+		if apartment != nil && !available && (viewerID == "" || apartment.Owner != viewerID) {
+			apartment = nil
+		}
+		view.Apartments = append(view.Apartments, SavedApartment{
+			WishlistItem: item,
+			Available:    available,
+			Apartment:    apartment,
+		})
+	}
+	return view, nil
+}
+
+// markSaved sets Saved on the apartments viewerID has in any of their wishlists. Saved flags are a nicety,
+// a failure leaves them unset instead of failing the listing.
+func (s *service) markSaved(ctx context.Context, viewerID string, apartments []*Apartment) {
+	if viewerID == "" || len(apartments) == 0 {
+		return
+	}
+	apartmentIDs := make([]string, 0, len(apartments))
+	for _, apartment := range apartments {
+		apartmentIDs = append(apartmentIDs, apartment.ID.Hex())
+	}
+	savedIDs, err := s.wr.GetSavedApartmentIDs(ctx, viewerID, apartmentIDs)
+	if err != nil {
+		return
+	}
+	saved := make(map[string]bool, len(savedIDs))
+	for _, id := range savedIDs {
+		saved[id] = true
+	}
+	for _, apartment := range apartments {
+		apartment.Saved = saved[apartment.ID.Hex()]
+	}
+}
+
+func apartmentRefs(apartments []Apartment) []*Apartment {
+	refs := make([]*Apartment, 0, len(apartments))
+	for i := range apartments {
+		refs = append(refs, &apartments[i])
+	}
+	return refs
+}
+
+func distanceApartmentRefs(apartments []ApartmentWithDistance) []*Apartment {
+	refs := make([]*Apartment, 0, len(apartments))
+	for i := range apartments {
+		refs = append(refs, &apartments[i].Apartment)
+	}
+	return refs
+}
+
+func newShareToken() (string, error) {
+	token := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,79 @@
+package apartments
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sharedWishlist is the only wishlist, shared with any token.
+type sharedWishlist struct {
+	WishlistRepository
+	wishlist Wishlist
+}
+
+func (r sharedWishlist) GetWishlistByID(context.Context, string) (*Wishlist, error) {
+	wishlist := r.wishlist
+	return &wishlist, nil
+}
+
+func (r sharedWishlist) GetWishlistByShareToken(context.Context, string) (*Wishlist, error) {
+	wishlist := r.wishlist
+	return &wishlist, nil
+}
+
+func TestWishlistViewVisibility(t *testing.T) {
+	apartments := []Apartment{
+		{ID: primitive.NewObjectID(), Owner: "host", Status: StatusPublished},
+		{ID: primitive.NewObjectID(), Owner: "host", Status: StatusDraft},
+		{ID: primitive.NewObjectID(), Owner: "host", Status: StatusPendingReview},
+		{ID: primitive.NewObjectID(), Owner: "host", Status: StatusArchived},
+		{ID: primitive.NewObjectID(), Owner: "guest", Status: StatusDraft},
+	}
+	repository := &memoryRepository{apartments: map[string]Apartment{}}
+	wishlist := Wishlist{Owner: "guest", ShareToken: "token"}
+	for _, apartment := range apartments {
+		repository.apartments[apartment.ID.Hex()] = apartment
+		wishlist.Items = append(wishlist.Items, WishlistItem{ApartmentID: apartment.ID.Hex()})
+	}
+	// The last item is an apartment deleted since it was saved.
+	wishlist.Items = append(wishlist.Items, WishlistItem{ApartmentID: primitive.NewObjectID().Hex()})
+	s := NewService(repository, nil, nil, sharedWishlist{wishlist: wishlist})
+
+	tests := []struct {
+		name  string
+		view  func() (*WishlistView, error)
+		shown []bool
+	}{
+		{
+			name:  "owner",
+			view:  func() (*WishlistView, error) { return s.GetWishlist(context.Background(), "guest", "id") },
+			shown: []bool{true, false, false, false, true, false},
+		},
+		{
+			name:  "shared",
+			view:  func() (*WishlistView, error) { return s.GetSharedWishlist(context.Background(), "token") },
+			shown: []bool{true, false, false, false, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view, err := tt.view()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(view.Apartments) != len(tt.shown) {
+				t.Fatalf("got %d apartments, want %d", len(view.Apartments), len(tt.shown))
+			}
+			for i, saved := range view.Apartments {
+				if shown := saved.Apartment != nil; shown != tt.shown[i] {
+					t.Errorf("item %d: shown %v, want %v", i, shown, tt.shown[i])
+				}
+				if want := i < len(apartments) && apartments[i].Status == StatusPublished; saved.Available != want {
+					t.Errorf("item %d: available %v, want %v", i, saved.Available, want)
+				}
+			}
+		})
+	}
+}
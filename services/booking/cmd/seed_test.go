@@ -0,0 +1,56 @@
+package main
+
+import (
+	"booking/pkg/booking"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeedApartmentReservations(t *testing.T) {
+	anchor := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	apartments := []*booking.Apartment{
+		{ID: seedObjectID(1, "apartment:0").Hex(), Owner: "owner", Price: 80, TimeZone: "Europe/Lisbon"},
+		{ID: seedObjectID(1, "apartment:1").Hex(), Owner: "owner", Price: 120, TimeZone: "Asia/Tokyo"},
+		{ID: seedObjectID(1, "apartment:2").Hex(), Owner: "owner", Price: 60},
+	}
+	for _, apartment := range apartments {
+		reservations := seedApartmentReservations(1, apartment, 30, 20, anchor)
+		if again := seedApartmentReservations(1, apartment, 30, 20, anchor); !reflect.DeepEqual(reservations, again) {
+			t.Errorf("%s: got different reservations from the same seed", apartment.ID)
+		}
+		if len(reservations) > 20 {
+			t.Errorf("%s: got %d reservations, want at most 20", apartment.ID, len(reservations))
+		}
+		ids := make(map[string]bool, len(reservations))
+		for i, reservation := range reservations {
+			if ids[reservation.ID.Hex()] {
+				t.Errorf("%s: got id %s twice", apartment.ID, reservation.ID.Hex())
+			}
+			ids[reservation.ID.Hex()] = true
+			if reservation.ApartmentID != apartment.ID || reservation.Nights < 1 {
+				t.Errorf("%s: got reservation %+v", apartment.ID, reservation)
+			}
+			if i > 0 && reservation.CheckIn.Before(reservations[i-1].CheckOut) {
+				t.Errorf("%s: reservation %d starts %s, before the previous one ends %s",
+					apartment.ID, i, reservation.CheckIn, reservations[i-1].CheckOut)
+			}
+		}
+	}
+}
+
+func TestSeedApartmentReservationsSeeds(t *testing.T) {
+	anchor := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	apartment := &booking.Apartment{ID: seedObjectID(1, "apartment:0").Hex(), Owner: "owner", Price: 80}
+	first, second := seedApartmentReservations(1, apartment, 30, 20, anchor), seedApartmentReservations(2, apartment, 30, 20, anchor)
+	if reflect.DeepEqual(first, second) {
+		t.Error("got the same reservations from different seeds")
+	}
+}
+
+// TestSeedObjectID pins the derived ids, the apartments seed command has the same test.
+func TestSeedObjectID(t *testing.T) {
+	if got, want := seedObjectID(1, "apartment:0").Hex(), "cc35391657502940ba024cd2"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -2,64 +2,100 @@ package booking
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 
-	natstransport "github.com/go-kit/kit/transport/nats"
+	"contracts/pkg/contracts"
+	nats_tracing "contracts/pkg/nats-tracing"
+
 	"github.com/nats-io/nats.go"
-	"github.com/openzipkin/zipkin-go"
-	"github.com/sergey-suslov/go-kit-nats-zipkin-tracing/natszipkin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const getApartmentByIDSubject = "apartments.getApartmentById"
-
 var ErrColdNotGetResponseFromApartment = errors.New("could not get response from the apartment service, wrong response format")
 
 type ApartmentsRepositoryNATS struct {
 	nc     *nats.Conn
-	tracer *zipkin.Tracer
+	tracer trace.TracerProvider
+	// contentType is the encoding of requests, see contracts.SupportedContentType.
+	contentType string
+	traceOption nats_tracing.TracerOption
 }
 
-func NewApartmentsRepository(nc *nats.Conn, tracer *zipkin.Tracer) *ApartmentsRepositoryNATS {
-	return &ApartmentsRepositoryNATS{nc: nc, tracer: tracer}
+func NewApartmentsRepository(
+	nc *nats.Conn,
+	tracer trace.TracerProvider,
+	contentType string,
+	traceOptions ...nats_tracing.TracerOption,
+) *ApartmentsRepositoryNATS {
+	return &ApartmentsRepositoryNATS{nc: nc, tracer: tracer, contentType: contentType, traceOption: nats_tracing.Options(traceOptions...)}
 }
 
-type getApartmentByIDRequest struct {
-	ApartmentID string `json:"apartmentId"`
-}
-
-type getApartmentByIDResponse struct {
-	Apartment Apartment `json:"apartment"`
-	Err       error     `json:"err,omitempty"`
+// GetApartmentByID requests an apartment on behalf of userID, apartments which are not published are only
+// returned to their owners.
+func (a *ApartmentsRepositoryNATS) GetApartmentByID(ctx context.Context, userID, apartmentID string) (*Apartment, error) {
+	publisher := contracts.NewPublisher(
+		a.nc,
+		contracts.SubjectGetApartmentByID,
+		contracts.NewRequestEncoder(a.contentType),
+		contracts.DecodeGetApartmentByIDResponse,
+		nats_tracing.NATSPublisherTrace(a.tracer, a.traceOption, nats_tracing.SetName("book an apartment")),
+	)
+	res, err := publisher.Endpoint()(ctx, contracts.GetApartmentByIDRequest{ApartmentID: apartmentID, UserID: userID})
+	if err != nil {
+		return nil, requestError(err)
+	}
+	response, ok := res.(contracts.GetApartmentByIDResponse)
+	if !ok {
+		return nil, ErrColdNotGetResponseFromApartment
+	}
+	if response.Err != nil {
+		return nil, fromApartmentsReply(response.Err)
+	}
+	if response.Apartment == nil || response.Apartment.ID == "" {
+		return nil, ErrColdNotGetResponseFromApartment
+	}
+	return response.Apartment, nil
 }
 
-func (a *ApartmentsRepositoryNATS) GetApartmentByID(ctx context.Context, apartmentID string) (*Apartment, error) {
-	publisher := natstransport.NewPublisher(
+// GetApartmentsByOwner requests all apartments of the owner, whatever their status.
+func (a *ApartmentsRepositoryNATS) GetApartmentsByOwner(ctx context.Context, ownerID string) ([]Apartment, error) {
+	publisher := contracts.NewPublisher(
 		a.nc,
-		getApartmentByIDSubject,
-		natstransport.EncodeJSONRequest,
-		decodeGetApartmentByID,
-		natszipkin.NATSPublisherTrace(a.tracer, natszipkin.Name("book an apartment")),
+		contracts.SubjectGetApartmentsByOwner,
+		contracts.NewRequestEncoder(a.contentType),
+		contracts.DecodeGetApartmentsByOwnerResponse,
+		nats_tracing.NATSPublisherTrace(a.tracer, a.traceOption, nats_tracing.SetName("get owner apartments")),
 	)
-	res, err := publisher.Endpoint()(ctx, getApartmentByIDRequest{ApartmentID: apartmentID})
+	res, err := publisher.Endpoint()(ctx, contracts.GetApartmentsByOwnerRequest{OwnerID: ownerID})
 	if err != nil {
-		return nil, err
+		return nil, requestError(err)
 	}
-	response, ok := res.(getApartmentByIDResponse)
+	response, ok := res.(contracts.GetApartmentsByOwnerResponse)
 	if !ok {
 		return nil, ErrColdNotGetResponseFromApartment
 	}
-	if response.Apartment.ID == "" {
-		return nil, ErrNoApartmentWithGivenID
+	if response.Err != nil {
+		return nil, fromApartmentsReply(response.Err)
 	}
-	return &response.Apartment, nil
+	return response.Apartments, nil
 }
 
-func decodeGetApartmentByID(_ context.Context, msg *nats.Msg) (response interface{}, err error) {
-	var res getApartmentByIDResponse
-	err = json.Unmarshal(msg.Data, &res)
-	if err != nil {
-		return nil, err
+// Ping requests the ping subject of the apartments service, which is reachable when it replies. Ping does not
+// touch apartments and is not traced.
+func (a *ApartmentsRepositoryNATS) Ping(ctx context.Context) error {
+	if _, err := a.nc.RequestWithContext(ctx, contracts.SubjectPingApartments, nil); err != nil {
+		if err == nats.ErrNoResponders {
+			return ErrApartmentsUnavailable
+		}
+		return requestError(err)
+	}
+	return nil
+}
+
+// requestError reports requests nobody answered in time as ErrApartmentsUnavailable, they are worth retrying.
+func requestError(err error) error {
+	if err == nats.ErrTimeout || err == context.DeadlineExceeded {
+		return ErrApartmentsUnavailable
 	}
-	return res, nil
+	return err
 }
@@ -0,0 +1,54 @@
+package booking
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Date is a reservation date sent by clients, either as 2006-01-02 or as an RFC 3339 timestamp.
+// Only the calendar date as written is meaningful, it is resolved in the apartment time zone.
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, value)
+	}
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// apartmentLocation returns the time zone of the apartment, UTC when it is unknown.
+func apartmentLocation(apartment *Apartment) *time.Location {
+	if apartment.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(apartment.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// nightsBetween counts the nights between two midnights of the same time zone, DST changes included.
+func nightsBetween(start, end time.Time) int {
+	return int(math.Round(end.Sub(start).Hours() / 24))
+}
+
+// startOfDay returns midnight of the calendar date of t, as written by the client, in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
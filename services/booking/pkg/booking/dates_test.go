@@ -0,0 +1,91 @@
+package booking
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    time.Time
+		wantErr bool
+	}{
+		{data: `"2021-06-01"`, want: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{data: `"2021-06-01T23:30:00-05:00"`, want: time.Date(2021, 6, 1, 23, 30, 0, 0, time.FixedZone("", -5*3600))},
+		{data: `"01/06/2021"`, wantErr: true},
+		{data: `20210601`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var d Date
+		err := json.Unmarshal([]byte(tt.data), &d)
+		if (err != nil) != tt.wantErr || err == nil && !d.Equal(tt.want) {
+			t.Errorf("%s: got %s and error %v, want %s", tt.data, d.Time, err, tt.want)
+		}
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	paris := loadLocation(t, "Europe/Paris")
+	tests := []struct {
+		t    time.Time
+		want time.Time
+	}{
+		{t: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), want: time.Date(2021, 6, 1, 0, 0, 0, 0, paris)},
+		// The calendar date as written is kept, even though it is already the next day in Paris.
+		{t: time.Date(2021, 6, 1, 23, 30, 0, 0, time.FixedZone("", -5*3600)), want: time.Date(2021, 6, 1, 0, 0, 0, 0, paris)},
+		// Summer time starts at 2am, the day is 23 hours long.
+		{t: time.Date(2021, 3, 28, 12, 0, 0, 0, time.UTC), want: time.Date(2021, 3, 28, 0, 0, 0, 0, paris)},
+	}
+	for _, tt := range tests {
+		if got := startOfDay(tt.t, paris); !got.Equal(tt.want) || got.Location() != paris {
+			t.Errorf("%s: got %s, want %s", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestNightsBetween(t *testing.T) {
+	paris := loadLocation(t, "Europe/Paris")
+	tests := []struct {
+		start, end time.Time
+		want       int
+	}{
+		{start: time.Date(2021, 6, 1, 0, 0, 0, 0, paris), end: time.Date(2021, 6, 2, 0, 0, 0, 0, paris), want: 1},
+		{start: time.Date(2021, 6, 1, 0, 0, 0, 0, paris), end: time.Date(2021, 6, 8, 0, 0, 0, 0, paris), want: 7},
+		// The night summer time starts is 23 hours long, the night it ends 25 hours.
+		{start: time.Date(2021, 3, 27, 0, 0, 0, 0, paris), end: time.Date(2021, 3, 29, 0, 0, 0, 0, paris), want: 2},
+		{start: time.Date(2021, 10, 30, 0, 0, 0, 0, paris), end: time.Date(2021, 11, 1, 0, 0, 0, 0, paris), want: 2},
+		{start: time.Date(2021, 6, 1, 0, 0, 0, 0, paris), end: time.Date(2021, 6, 1, 0, 0, 0, 0, paris)},
+	}
+	for _, tt := range tests {
+		if got := nightsBetween(tt.start, tt.end); got != tt.want {
+			t.Errorf("%s to %s: got %d nights, want %d", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestApartmentLocation(t *testing.T) {
+	tests := []struct {
+		timeZone string
+		want     string
+	}{
+		{timeZone: "Europe/Lisbon", want: "Europe/Lisbon"},
+		{timeZone: "", want: "UTC"},
+		{timeZone: "Mars/Olympus_Mons", want: "UTC"},
+	}
+	for _, tt := range tests {
+		if got := apartmentLocation(&Apartment{TimeZone: tt.timeZone}); got.String() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.timeZone, got, tt.want)
+		}
+	}
+}
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"contracts/pkg/contracts"
+
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -44,9 +46,9 @@ func makeGetApartmentsEndpoint(s Service) endpoint.Endpoint {
 
 type bookRequest struct {
 	UserClaim
-	ApartmentID string    `json:"apartmentId"`
-	Start       time.Time `json:"start"`
-	End         time.Time `json:"end"`
+	ApartmentID string `json:"apartmentId"`
+	Start       Date   `json:"start"`
+	End         Date   `json:"end"`
 }
 
 func (c *bookRequest) SetUserClaim(claim *UserClaim) {
@@ -65,7 +67,172 @@ func (g booksResponse) Error() error {
 func makeBookApartmentEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(*bookRequest)
-		reservation, err := s.BookApartment(ctx, req.ID, req.ApartmentID, req.Start, req.End)
+		reservation, err := s.BookApartment(ctx, req.ID, req.ApartmentID, req.Start.Time, req.End.Time)
+		return booksResponse{Reservation: reservation, Err: err}, nil
+	}
+}
+
+func makeHasFutureReservationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(contracts.HasFutureReservationsRequest)
+		hasReservations, err := s.HasFutureReservations(ctx, req.ApartmentID)
+		return contracts.HasFutureReservationsResponse{
+			HasFutureReservations: hasReservations,
+			Err:                   newReplyError(err),
+		}, nil
+	}
+}
+
+func makeGetBusyApartmentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(contracts.GetBusyApartmentsRequest)
+		apartmentIDs, err := s.GetBusyApartmentIDs(ctx, req.CheckIn, req.CheckOut)
+		return contracts.GetBusyApartmentsResponse{
+			ApartmentIDs: apartmentIDs,
+			Err:          newReplyError(err),
+		}, nil
+	}
+}
+
+type cancelReservationRequest struct {
+	UserClaim
+	ReservationID string
+}
+
+func (c *cancelReservationRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+func makeCancelReservationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*cancelReservationRequest)
+		reservation, err := s.CancelReservation(ctx, req.ID, req.ReservationID)
 		return booksResponse{Reservation: reservation, Err: err}, nil
 	}
 }
+
+type ownerReportRequest struct {
+	UserClaim
+	From time.Time
+	To   time.Time
+}
+
+func (c *ownerReportRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type ownerReportResponse struct {
+	*OwnerReport
+	Err error `json:"error,omitempty"`
+}
+
+func (o ownerReportResponse) Error() error {
+	return o.Err
+}
+
+func makeGetOwnerReportEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*ownerReportRequest)
+		report, err := s.GetOwnerReport(ctx, req.ID, req.From, req.To)
+		return ownerReportResponse{OwnerReport: report, Err: err}, nil
+	}
+}
+
+type startThreadRequest struct {
+	UserClaim
+	ApartmentID   string `json:"apartmentId"`
+	ReservationID string `json:"reservationId"`
+	Body          string `json:"body"`
+}
+
+func (c *startThreadRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type threadMessagesResponse struct {
+	*ThreadMessages
+	Err error `json:"error,omitempty"`
+}
+
+func (t threadMessagesResponse) Error() error {
+	return t.Err
+}
+
+func makeStartThreadEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*startThreadRequest)
+		thread, err := s.StartThread(ctx, req.ID, req.ApartmentID, req.ReservationID, req.Body)
+		return threadMessagesResponse{ThreadMessages: thread, Err: err}, nil
+	}
+}
+
+type threadRequest struct {
+	UserClaim
+	ThreadID string `json:"-"`
+	Before   string `json:"-"`
+	Limit    int    `json:"-"`
+	Body     string `json:"body"`
+}
+
+func (c *threadRequest) SetUserClaim(claim *UserClaim) {
+	c.UserClaim = *claim
+}
+
+type threadsResponse struct {
+	Threads []Thread `json:"threads"`
+	Unread  int      `json:"unread"`
+	Err     error    `json:"error,omitempty"`
+}
+
+func (t threadsResponse) Error() error {
+	return t.Err
+}
+
+func makeGetThreadsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*threadRequest)
+		threads, unread, err := s.GetThreads(ctx, req.ID)
+		return threadsResponse{Threads: threads, Unread: unread, Err: err}, nil
+	}
+}
+
+func makeGetMessagesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*threadRequest)
+		messages, err := s.GetMessages(ctx, req.ID, req.ThreadID, req.Before, req.Limit)
+		return threadMessagesResponse{ThreadMessages: messages, Err: err}, nil
+	}
+}
+
+type messageResponse struct {
+	Message *Message `json:"message,omitempty"`
+	Err     error    `json:"error,omitempty"`
+}
+
+func (m messageResponse) Error() error {
+	return m.Err
+}
+
+func makePostMessageEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*threadRequest)
+		message, err := s.PostMessage(ctx, req.ID, req.ThreadID, req.Body)
+		return messageResponse{Message: message, Err: err}, nil
+	}
+}
+
+type markThreadReadResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (m markThreadReadResponse) Error() error {
+	return m.Err
+}
+
+func makeMarkThreadReadEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*threadRequest)
+		err := s.MarkThreadRead(ctx, req.ID, req.ThreadID)
+		return markThreadReadResponse{Err: err}, nil
+	}
+}
@@ -0,0 +1,26 @@
+package booking
+
+import (
+	"context"
+
+	"contracts/pkg/contracts"
+
+	"github.com/nats-io/nats.go"
+)
+
+// EventPublisherNATS publishes events to plain NATS subjects, nobody is expected to reply.
+type EventPublisherNATS struct {
+	nc *nats.Conn
+}
+
+func NewEventPublisher(nc *nats.Conn) *EventPublisherNATS {
+	return &EventPublisherNATS{nc: nc}
+}
+
+func (p *EventPublisherNATS) PublishMessageCreated(_ context.Context, event MessageCreatedEvent) error {
+	data, err := contracts.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.nc.Publish(contracts.SubjectMessageCreated, data)
+}
@@ -2,35 +2,128 @@ package booking
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"contracts/pkg/contracts"
+	"telemetry/pkg/telemetry"
+
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/metrics"
 )
 
-type InstrumentingService struct {
-	requestCount   metrics.Counter
-	requestLatency metrics.Histogram
-	Service
+// Transports label the metrics of requests with the way they were received.
+const (
+	TransportHTTP = "http"
+	TransportNATS = "nats"
+)
+
+// EndpointMetrics are the metrics of the requests served by endpoints, their count and their duration in
+// seconds. The zero EndpointMetrics records nothing.
+type EndpointMetrics struct {
+	Requests metrics.Counter
+	Duration metrics.Histogram
+}
+
+// InstrumentingMiddleware records the requests for method received over transport, labelled by method,
+// transport and the class of their error, see telemetry.ErrorClass.
+func InstrumentingMiddleware(m EndpointMetrics, method, transport string) endpoint.Middleware {
+	if m.Requests == nil || m.Duration == nil {
+		return func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+	}
+	requests := m.Requests.With("method", method, "transport", transport)
+	duration := m.Duration.With("method", method, "transport", transport)
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				class := errorClass(responseError(response, err))
+				requests.With("error", class).Add(1)
+				duration.With("error", class).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
 }
 
-func NewInstrumentingService(requestCount metrics.Counter, requestLatency metrics.Histogram, service Service) *InstrumentingService {
-	return &InstrumentingService{requestCount: requestCount, requestLatency: requestLatency, Service: service}
+// responseError is the error of the endpoint or else the one its response carries.
+func responseError(response interface{}, err error) error {
+	if err == nil {
+		if errorer, ok := response.(Errorer); ok {
+			return errorer.Error()
+		}
+	}
+	return err
 }
 
-func (i *InstrumentingService) GetReservations(ctx context.Context, apartmentID string, start, end time.Time) (out []Reservation, err error) { //nolint:lll
-	defer func(begin time.Time) {
-		i.requestCount.With("method", "GetReservations").Add(1)
-		i.requestLatency.With("method", "GetReservations").Observe(time.Since(begin).Seconds())
-	}(time.Now())
+// errorClass classifies errors replied over NATS by their code and the others by their HTTP status.
+func errorClass(err error) string {
+	var replyErr *contracts.ReplyError
+	switch {
+	case err == nil:
+		return telemetry.ErrorClassNone
+	case errors.As(err, &replyErr):
+		return string(replyErr.Code)
+	}
+	return telemetry.ErrorClass(statusOf(err))
+}
+
+// InstrumentingService counts the bookings made and the ones rejected because they overlap a reservation, the
+// requests themselves are recorded by InstrumentingMiddleware.
+type InstrumentingService struct {
+	bookingsCreated   metrics.Counter
+	conflictsRejected metrics.Counter
+	Service
+}
 
-	return i.Service.GetReservations(ctx, apartmentID, start, end)
+func NewInstrumentingService(bookingsCreated, conflictsRejected metrics.Counter, service Service) *InstrumentingService {
+	return &InstrumentingService{bookingsCreated: bookingsCreated, conflictsRejected: conflictsRejected, Service: service}
 }
 
 func (i *InstrumentingService) BookApartment(ctx context.Context, userID, apartmentID string, start, end time.Time) (out *Reservation, err error) { //nolint:lll
-	defer func(begin time.Time) {
-		i.requestCount.With("method", "BookApartment").Add(1)
-		i.requestLatency.With("method", "BookApartment").Observe(time.Since(begin).Seconds())
-	}(time.Now())
+	defer func() {
+		switch {
+		case err == nil:
+			i.bookingsCreated.Add(1)
+		case errors.Is(err, ErrReservationOverlaps):
+			i.conflictsRejected.Add(1)
+		}
+	}()
 
 	return i.Service.BookApartment(ctx, userID, apartmentID, start, end)
 }
+
+// InstrumentingApartmentsRepository counts the apartments which could not be looked up in the apartments
+// service, labelled by method and error class.
+type InstrumentingApartmentsRepository struct {
+	lookupsFailed metrics.Counter
+	ApartmentsRepository
+}
+
+func NewInstrumentingApartmentsRepository(
+	lookupsFailed metrics.Counter,
+	repository ApartmentsRepository,
+) *InstrumentingApartmentsRepository {
+	return &InstrumentingApartmentsRepository{lookupsFailed: lookupsFailed, ApartmentsRepository: repository}
+}
+
+func (i *InstrumentingApartmentsRepository) GetApartmentByID(ctx context.Context, userID, apartmentID string) (out *Apartment, err error) {
+	defer func() {
+		if err != nil {
+			i.lookupsFailed.With("method", "GetApartmentByID", "error", errorClass(err)).Add(1)
+		}
+	}()
+
+	return i.ApartmentsRepository.GetApartmentByID(ctx, userID, apartmentID)
+}
+
+func (i *InstrumentingApartmentsRepository) GetApartmentsByOwner(ctx context.Context, ownerID string) (out []Apartment, err error) {
+	defer func() {
+		if err != nil {
+			i.lookupsFailed.With("method", "GetApartmentsByOwner", "error", errorClass(err)).Add(1)
+		}
+	}()
+
+	return i.ApartmentsRepository.GetApartmentsByOwner(ctx, ownerID)
+}
@@ -0,0 +1,43 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/generic"
+)
+
+// bookedService books apartments with the error it is given.
+type bookedService struct {
+	Service
+	err error
+}
+
+func (s bookedService) BookApartment(context.Context, string, string, time.Time, time.Time) (*Reservation, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Reservation{}, nil
+}
+
+func TestInstrumentingServiceBookApartment(t *testing.T) {
+	tests := []struct {
+		err                error
+		created, conflicts float64
+	}{
+		{err: nil, created: 1},
+		{err: ErrReservationOverlaps, conflicts: 1},
+		{err: ErrApartmentNotPublished},
+		{err: ErrReservationCancelled},
+		{err: ErrApartmentsUnavailable},
+	}
+	for _, tt := range tests {
+		created, conflicts := generic.NewCounter("created"), generic.NewCounter("conflicts")
+		service := NewInstrumentingService(created, conflicts, bookedService{err: tt.err})
+		_, _ = service.BookApartment(context.Background(), "guest", "apartment", time.Now(), time.Now())
+		if created.Value() != tt.created || conflicts.Value() != tt.conflicts {
+			t.Errorf("%v: %v created and %v conflicts, want %v and %v", tt.err, created.Value(), conflicts.Value(), tt.created, tt.conflicts)
+		}
+	}
+}
@@ -37,3 +37,112 @@ func (s *loggingService) BookApartment(ctx context.Context, userID, apartmentID
 	}(time.Now())
 	return s.Service.BookApartment(ctx, userID, apartmentID, start, end)
 }
+
+func (s *loggingService) HasFutureReservations(ctx context.Context, apartmentID string) (out bool, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling HasFutureReservations",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("apartmentID", apartmentID),
+			zap.Bool("has future reservations", out),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.HasFutureReservations(ctx, apartmentID)
+}
+
+func (s *loggingService) GetBusyApartmentIDs(ctx context.Context, start, end time.Time) (out []string, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetBusyApartmentIDs",
+			zap.Duration("took", time.Since(begin)),
+			zap.Int("returned apartments", len(out)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetBusyApartmentIDs(ctx, start, end)
+}
+
+func (s *loggingService) CancelReservation(ctx context.Context, userID, reservationID string) (out *Reservation, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling CancelReservation",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("reservationID", reservationID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.CancelReservation(ctx, userID, reservationID)
+}
+
+func (s *loggingService) GetOwnerReport(ctx context.Context, ownerID string, from, to time.Time) (out *OwnerReport, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetOwnerReport",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("ownerID", ownerID),
+			zap.Time("from", from),
+			zap.Time("to", to),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetOwnerReport(ctx, ownerID, from, to)
+}
+
+func (s *loggingService) StartThread(ctx context.Context, userID, apartmentID, reservationID, body string) (out *ThreadMessages, err error) { //nolint:lll
+	defer func(begin time.Time) {
+		s.logger.Debug("calling StartThread",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("userID", userID),
+			zap.String("apartmentID", apartmentID),
+			zap.String("reservationID", reservationID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.StartThread(ctx, userID, apartmentID, reservationID, body)
+}
+
+func (s *loggingService) GetThreads(ctx context.Context, userID string) (threads []Thread, unread int, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetThreads",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("userID", userID),
+			zap.Int("returned threads", len(threads)),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetThreads(ctx, userID)
+}
+
+func (s *loggingService) GetMessages(ctx context.Context, userID, threadID, before string, limit int) (out *ThreadMessages, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling GetMessages",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("userID", userID),
+			zap.String("threadID", threadID),
+			zap.String("before", before),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.GetMessages(ctx, userID, threadID, before, limit)
+}
+
+func (s *loggingService) PostMessage(ctx context.Context, userID, threadID, body string) (out *Message, err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling PostMessage",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("userID", userID),
+			zap.String("threadID", threadID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.PostMessage(ctx, userID, threadID, body)
+}
+
+func (s *loggingService) MarkThreadRead(ctx context.Context, userID, threadID string) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Debug("calling MarkThreadRead",
+			zap.Duration("took", time.Since(begin)),
+			zap.String("userID", userID),
+			zap.String("threadID", threadID),
+			zap.Error(err),
+		)
+	}(time.Now())
+	return s.Service.MarkThreadRead(ctx, userID, threadID)
+}
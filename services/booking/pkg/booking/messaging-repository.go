@@ -0,0 +1,185 @@
+package booking
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const threadCollectionName = "threads"
+const messageCollectionName = "messages"
+
+type MongoMessagingRepository struct {
+	db *mongo.Database
+}
+
+func NewMessagingRepository(db *mongo.Database) *MongoMessagingRepository {
+	return &MongoMessagingRepository{db: db}
+}
+
+// EnsureIndexes creates the indexes used by thread and message queries. It is safe to call on every start.
+func (r *MongoMessagingRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.db.Collection(threadCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "apartmentId", Value: 1}, {Key: "guestId", Value: 1}, {Key: "reservationId", Value: 1}},
+			Options: options.Index().SetName("apartmentId_guestId_reservationId").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "guestId", Value: 1}, {Key: "lastMessageAt", Value: -1}},
+			Options: options.Index().SetName("guestId_lastMessageAt"),
+		},
+		{
+			Keys:    bson.D{{Key: "ownerId", Value: 1}, {Key: "lastMessageAt", Value: -1}},
+			Options: options.Index().SetName("ownerId_lastMessageAt"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection(messageCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "threadId", Value: 1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("threadId_id"),
+	})
+	return err
+}
+
+// FindOrCreateThread upserts the thread. Two concurrent upserts may both insert, the loser of the unique index
+// reads the thread of the winner.
+func (r *MongoMessagingRepository) FindOrCreateThread(ctx context.Context, thread *Thread) (*Thread, error) {
+	filter := bson.D{
+		{Key: "apartmentId", Value: thread.ApartmentID},
+		{Key: "guestId", Value: thread.GuestID},
+		{Key: "reservationId", Value: thread.ReservationID},
+	}
+	result := r.db.Collection(threadCollectionName).FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.D{{Key: "$setOnInsert", Value: bson.D{
+			{Key: "ownerId", Value: thread.OwnerID},
+			{Key: "created", Value: thread.Created},
+			{Key: "lastMessageAt", Value: thread.LastMessageAt},
+			{Key: "lastMessage", Value: ""},
+			{Key: "guestUnread", Value: 0},
+			{Key: "ownerUnread", Value: 0},
+		}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	if isDuplicateKey(result.Err()) {
+		result = r.db.Collection(threadCollectionName).FindOne(ctx, filter)
+	}
+	var found Thread
+	if err := result.Decode(&found); err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return &found, nil
+}
+
+func (r *MongoMessagingRepository) GetThreadByID(ctx context.Context, threadID string) (*Thread, error) {
+	objectID, err := primitive.ObjectIDFromHex(threadID)
+	if err != nil {
+		return nil, ErrWrongIDFormat
+	}
+	var thread Thread
+	err = r.db.Collection(threadCollectionName).FindOne(ctx, bson.D{{Key: "_id", Value: objectID}}).Decode(&thread)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrThreadNotFound
+	}
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return &thread, nil
+}
+
+func (r *MongoMessagingRepository) GetThreadsByParticipant(ctx context.Context, userID string) ([]Thread, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "lastMessageAt", Value: -1}})
+	query := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "guestId", Value: userID}},
+		bson.D{{Key: "ownerId", Value: userID}},
+	}}}
+	cursor, err := r.db.Collection(threadCollectionName).Find(ctx, query, opts)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	threads := make([]Thread, 0)
+	err = cursor.All(ctx, &threads)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return threads, nil
+}
+
+func (r *MongoMessagingRepository) AddMessage(ctx context.Context, message *Message, recipient Participant) (*Message, error) {
+	threadID, err := primitive.ObjectIDFromHex(message.ThreadID)
+	if err != nil {
+		return nil, ErrWrongIDFormat
+	}
+	result, err := r.db.Collection(messageCollectionName).InsertOne(ctx, message)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	message.ID = result.InsertedID.(primitive.ObjectID)
+
+	_, err = r.db.Collection(threadCollectionName).UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: threadID}},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "lastMessageAt", Value: message.Created},
+				{Key: "lastMessage", Value: messagePreview(message.Body)},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: unreadField(recipient), Value: 1}}},
+		},
+	)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return message, nil
+}
+
+func (r *MongoMessagingRepository) GetMessages(ctx context.Context, threadID, before string, limit int) ([]Message, error) {
+	query := bson.D{{Key: "threadId", Value: threadID}}
+	if before != "" {
+		beforeID, err := primitive.ObjectIDFromHex(before)
+		if err != nil {
+			return nil, ErrWrongIDFormat
+		}
+		query = append(query, bson.E{Key: "_id", Value: bson.D{{Key: "$lt", Value: beforeID}}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.db.Collection(messageCollectionName).Find(ctx, query, opts)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	messages := make([]Message, 0, limit)
+	err = cursor.All(ctx, &messages)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return messages, nil
+}
+
+func (r *MongoMessagingRepository) MarkThreadRead(ctx context.Context, threadID string, reader Participant) error {
+	objectID, err := primitive.ObjectIDFromHex(threadID)
+	if err != nil {
+		return ErrWrongIDFormat
+	}
+	_, err = r.db.Collection(threadCollectionName).UpdateOne(
+		ctx,
+		bson.D{{Key: "_id", Value: objectID}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: unreadField(reader), Value: 0}}}},
+	)
+	if err != nil {
+		return ErrRequestingDatabase
+	}
+	return nil
+}
+
+func unreadField(p Participant) string {
+	if p == ParticipantOwner {
+		return "ownerUnread"
+	}
+	return "guestUnread"
+}
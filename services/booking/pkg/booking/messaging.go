@@ -0,0 +1,275 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"contracts/pkg/contracts"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	maxMessageLength     = 2000
+	messagePreviewLength = 140
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 100
+)
+
+var ErrThreadNotFound = errors.New("thread not found")
+var ErrInvalidMessage = errors.New("message must be between 1 and 2000 characters long")
+var ErrOwnApartmentThread = errors.New("owners can not start threads about their own apartments")
+var ErrInvalidLimit = errors.New("limit must be an integer")
+
+// Participant is the side of a thread a user is on.
+type Participant string
+
+const (
+	ParticipantGuest Participant = "guest"
+	ParticipantOwner Participant = "owner"
+)
+
+// Thread is a conversation between a guest and the owner of an apartment, optionally about one reservation.
+// There is at most one thread per apartment, guest and reservation.
+type Thread struct {
+	ID            primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	ApartmentID   string             `json:"apartmentId" bson:"apartmentId"`
+	ReservationID string             `json:"reservationId,omitempty" bson:"reservationId"`
+	GuestID       string             `json:"guestId" bson:"guestId"`
+	OwnerID       string             `json:"ownerId" bson:"ownerId"`
+	Created       time.Time          `json:"created" bson:"created"`
+	LastMessageAt time.Time          `json:"lastMessageAt" bson:"lastMessageAt"`
+	LastMessage   string             `json:"lastMessage" bson:"lastMessage"`
+	GuestUnread   int                `json:"-" bson:"guestUnread"`
+	OwnerUnread   int                `json:"-" bson:"ownerUnread"`
+	// Unread is the number of messages the requesting participant has not read yet.
+	Unread int `json:"unread" bson:"-"`
+}
+
+// participant returns the side userID is on, or false when userID does not take part in the thread.
+func (t *Thread) participant(userID string) (Participant, bool) {
+	switch userID {
+	case t.GuestID:
+		return ParticipantGuest, true
+	case t.OwnerID:
+		return ParticipantOwner, true
+	}
+	return "", false
+}
+
+func (t *Thread) viewedBy(p Participant) {
+	t.Unread = t.GuestUnread
+	if p == ParticipantOwner {
+		t.Unread = t.OwnerUnread
+	}
+}
+
+type Message struct {
+	ID       primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+	ThreadID string             `json:"threadId" bson:"threadId"`
+	SenderID string             `json:"senderId" bson:"senderId"`
+	Body     string             `json:"body" bson:"body"`
+	Created  time.Time          `json:"created" bson:"created"`
+}
+
+type ThreadMessages struct {
+	Thread   *Thread   `json:"thread"`
+	Messages []Message `json:"messages"`
+	HasMore  bool      `json:"hasMore"`
+}
+
+// MessageCreatedEvent is published for every new message, so that the recipient can be notified.
+type MessageCreatedEvent = contracts.MessageCreatedEvent
+
+type MessagingRepository interface {
+	// FindOrCreateThread returns the thread of the same apartment, guest and reservation, creating it if needed.
+	FindOrCreateThread(ctx context.Context, thread *Thread) (*Thread, error)
+	GetThreadByID(ctx context.Context, threadID string) (*Thread, error)
+	GetThreadsByParticipant(ctx context.Context, userID string) ([]Thread, error)
+	// AddMessage stores the message and counts it as unread for the recipient.
+	AddMessage(ctx context.Context, message *Message, recipient Participant) (*Message, error)
+	// GetMessages returns up to limit messages older than the message with the id before, newest first.
+	GetMessages(ctx context.Context, threadID, before string, limit int) ([]Message, error)
+	MarkThreadRead(ctx context.Context, threadID string, reader Participant) error
+}
+
+type EventPublisher interface {
+	PublishMessageCreated(ctx context.Context, event MessageCreatedEvent) error
+}
+
+// StartThread opens a thread of the guest with the owner of the apartment, or continues the existing one,
+// with a first message. With a reservation, the apartment is the reserved one and the guest must have made it,
+// the thread can be started even if the apartment is not published anymore.
+func (s *service) StartThread(ctx context.Context, userID, apartmentID, reservationID, body string) (*ThreadMessages, error) {
+	body, err := normalizeMessageBody(body)
+	if err != nil {
+		return nil, err
+	}
+	ownerID := ""
+	if reservationID != "" {
+		reservation, lookupErr := s.r.GetReservationByID(ctx, reservationID)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if reservation.UserID != userID || (apartmentID != "" && reservation.ApartmentID != apartmentID) {
+			return nil, ErrReservationNotFound
+		}
+		apartmentID, ownerID = reservation.ApartmentID, reservation.OwnerID
+	}
+	// Reservations made before owners were kept on them fall back to the apartment, as threads without one.
+	if ownerID == "" {
+		apartment, lookupErr := s.ar.GetApartmentByID(ctx, userID, apartmentID)
+		if lookupErr != nil {
+			return nil, s.apartmentsError(lookupErr)
+		}
+		apartmentID, ownerID = apartment.ID, apartment.Owner
+	}
+	if ownerID == userID {
+		return nil, ErrOwnApartmentThread
+	}
+
+	now := time.Now()
+	thread, err := s.mr.FindOrCreateThread(ctx, &Thread{
+		ApartmentID:   apartmentID,
+		ReservationID: reservationID,
+		GuestID:       userID,
+		OwnerID:       ownerID,
+		Created:       now,
+		LastMessageAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	message, err := s.postMessage(ctx, thread, userID, body)
+	if err != nil {
+		return nil, err
+	}
+	thread.LastMessageAt, thread.LastMessage = message.Created, messagePreview(message.Body)
+	thread.viewedBy(ParticipantGuest)
+	return &ThreadMessages{Thread: thread, Messages: []Message{*message}}, nil
+}
+
+// GetThreads returns the threads of the user, most recently active first, with the total of unread messages.
+func (s *service) GetThreads(ctx context.Context, userID string) ([]Thread, int, error) {
+	threads, err := s.mr.GetThreadsByParticipant(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread := 0
+	for i := range threads {
+		p, _ := threads[i].participant(userID)
+		threads[i].viewedBy(p)
+		unread += threads[i].Unread
+	}
+	return threads, unread, nil
+}
+
+// GetMessages pages through the messages of a thread from the newest, before is the id of the oldest message
+// of the previous page.
+func (s *service) GetMessages(ctx context.Context, userID, threadID, before string, limit int) (*ThreadMessages, error) {
+	thread, p, err := s.getParticipatedThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxMessagesLimit {
+		limit = defaultMessagesLimit
+	}
+	messages, err := s.mr.GetMessages(ctx, threadID, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	thread.viewedBy(p)
+	page := &ThreadMessages{Thread: thread, Messages: messages}
+	if len(messages) > limit {
+		page.Messages, page.HasMore = messages[:limit], true
+	}
+	return page, nil
+}
+
+func (s *service) PostMessage(ctx context.Context, userID, threadID, body string) (*Message, error) {
+	body, err := normalizeMessageBody(body)
+	if err != nil {
+		return nil, err
+	}
+	thread, _, err := s.getParticipatedThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.postMessage(ctx, thread, userID, body)
+}
+
+// MarkThreadRead resets the unread counter of the user in the thread.
+func (s *service) MarkThreadRead(ctx context.Context, userID, threadID string) error {
+	_, p, err := s.getParticipatedThread(ctx, userID, threadID)
+	if err != nil {
+		return err
+	}
+	return s.mr.MarkThreadRead(ctx, threadID, p)
+}
+
+// getParticipatedThread returns the thread if userID takes part in it. Threads of other users are reported
+// as not found.
+func (s *service) getParticipatedThread(ctx context.Context, userID, threadID string) (*Thread, Participant, error) {
+	thread, err := s.mr.GetThreadByID(ctx, threadID)
+	if err != nil {
+		return nil, "", err
+	}
+	p, ok := thread.participant(userID)
+	if !ok {
+		return nil, "", ErrThreadNotFound
+	}
+	return thread, p, nil
+}
+
+// postMessage stores the message and publishes its event. A failed event does not fail the message,
+// it is already delivered to the thread.
+func (s *service) postMessage(ctx context.Context, thread *Thread, senderID, body string) (*Message, error) {
+	recipient, recipientID := ParticipantOwner, thread.OwnerID
+	if senderID == thread.OwnerID {
+		recipient, recipientID = ParticipantGuest, thread.GuestID
+	}
+	message, err := s.mr.AddMessage(ctx, &Message{
+		ThreadID: thread.ID.Hex(),
+		SenderID: senderID,
+		Body:     body,
+		Created:  time.Now(),
+	}, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.events.PublishMessageCreated(ctx, MessageCreatedEvent{
+		MessageID:     message.ID.Hex(),
+		ThreadID:      message.ThreadID,
+		ApartmentID:   thread.ApartmentID,
+		ReservationID: thread.ReservationID,
+		SenderID:      senderID,
+		RecipientID:   recipientID,
+		Body:          message.Body,
+		Created:       message.Created,
+	})
+	if err != nil {
+		s.logger.Error("error publishing message created event", zap.String("messageID", message.ID.Hex()), zap.Error(err))
+	}
+	return message, nil
+}
+
+func normalizeMessageBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxMessageLength {
+		return "", ErrInvalidMessage
+	}
+	return body, nil
+}
+
+// messagePreview cuts the message to the length shown in thread lists.
+func messagePreview(body string) string {
+	if utf8.RuneCountInString(body) <= messagePreviewLength {
+		return body
+	}
+	return string([]rune(body)[:messagePreviewLength]) + "…"
+}
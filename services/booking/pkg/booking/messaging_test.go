@@ -0,0 +1,171 @@
+package booking
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// threads holds a single thread, it records who messages are unread for and who read the thread.
+type threads struct {
+	MessagingRepository
+	thread    *Thread
+	recipient Participant
+	reader    Participant
+}
+
+func (r *threads) FindOrCreateThread(_ context.Context, thread *Thread) (*Thread, error) {
+	thread.ID = primitive.NewObjectID()
+	r.thread = thread
+	return thread, nil
+}
+
+func (r *threads) GetThreadByID(context.Context, string) (*Thread, error) {
+	thread := *r.thread
+	return &thread, nil
+}
+
+func (r *threads) AddMessage(_ context.Context, message *Message, recipient Participant) (*Message, error) {
+	message.ID, r.recipient = primitive.NewObjectID(), recipient
+	return message, nil
+}
+
+func (r *threads) GetMessages(context.Context, string, string, int) ([]Message, error) {
+	return nil, nil
+}
+
+func (r *threads) MarkThreadRead(_ context.Context, _ string, reader Participant) error {
+	r.reader = reader
+	return nil
+}
+
+// events keeps the events published.
+type events struct {
+	published []MessageCreatedEvent
+}
+
+func (e *events) PublishMessageCreated(_ context.Context, event MessageCreatedEvent) error {
+	e.published = append(e.published, event)
+	return nil
+}
+
+func TestStartThread(t *testing.T) {
+	// Guests find published apartments only, "suspended" is not one of them.
+	ar := apartments{byID: map[string]Apartment{"published": {ID: "published", Owner: "owner", Status: ApartmentStatusPublished}},
+		err: ErrNoApartmentWithGivenID}
+	r := &reservations{byID: map[string]Reservation{
+		"current": {ApartmentID: "suspended", UserID: "guest", OwnerID: "owner"},
+		"older":   {ApartmentID: "suspended", UserID: "guest"},
+	}}
+	tests := []struct {
+		name          string
+		userID        string
+		apartmentID   string
+		reservationID string
+		wantErr       error
+	}{
+		{name: "published apartment", userID: "guest", apartmentID: "published"},
+		{name: "unpublished apartment", userID: "guest", apartmentID: "suspended", wantErr: ErrNoApartmentWithGivenID},
+		{name: "own apartment", userID: "owner", apartmentID: "published", wantErr: ErrOwnApartmentThread},
+		{name: "reservation of an unpublished apartment", userID: "guest", reservationID: "current"},
+		{name: "reservation and its apartment", userID: "guest", apartmentID: "suspended", reservationID: "current"},
+		{name: "reservation and another apartment", userID: "guest", apartmentID: "published", reservationID: "current",
+			wantErr: ErrReservationNotFound},
+		{name: "reservation of another guest", userID: "intruder", reservationID: "current", wantErr: ErrReservationNotFound},
+		{name: "reservation without owner", userID: "guest", reservationID: "older", wantErr: ErrNoApartmentWithGivenID},
+		{name: "missing reservation", userID: "guest", reservationID: "missing", wantErr: ErrReservationNotFound},
+	}
+	for _, tt := range tests {
+		mr, published := &threads{}, &events{}
+		s := NewService(r, ar, mr, published, zap.NewNop())
+		thread, err := s.StartThread(context.Background(), tt.userID, tt.apartmentID, tt.reservationID, " Hello ")
+		if err != tt.wantErr {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if mr.thread.OwnerID != "owner" || mr.thread.GuestID != tt.userID || thread.Messages[0].Body != "Hello" {
+			t.Errorf("%s: got thread of owner %q and guest %q with %+v", tt.name, mr.thread.OwnerID, mr.thread.GuestID, thread.Messages)
+		}
+		if got := published.published; len(got) != 1 || got[0].RecipientID != "owner" || got[0].SenderID != tt.userID {
+			t.Errorf("%s: got events %+v", tt.name, got)
+		}
+	}
+}
+
+func TestThreadParticipants(t *testing.T) {
+	thread := &Thread{ID: primitive.NewObjectID(), GuestID: "guest", OwnerID: "owner", GuestUnread: 1, OwnerUnread: 3}
+	tests := []struct {
+		userID        string
+		wantErr       error
+		wantRecipient Participant
+		wantReader    Participant
+		wantUnread    int
+	}{
+		{userID: "guest", wantRecipient: ParticipantOwner, wantReader: ParticipantGuest, wantUnread: 1},
+		{userID: "owner", wantRecipient: ParticipantGuest, wantReader: ParticipantOwner, wantUnread: 3},
+		{userID: "stranger", wantErr: ErrThreadNotFound},
+		{userID: "", wantErr: ErrThreadNotFound},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		mr := &threads{thread: thread}
+		s := NewService(&reservations{}, apartments{}, mr, &events{}, zap.NewNop())
+		if _, err := s.PostMessage(ctx, tt.userID, thread.ID.Hex(), "Hello"); err != tt.wantErr {
+			t.Errorf("%q posting: got error %v, want %v", tt.userID, err, tt.wantErr)
+		}
+		if err := s.MarkThreadRead(ctx, tt.userID, thread.ID.Hex()); err != tt.wantErr {
+			t.Errorf("%q marking read: got error %v, want %v", tt.userID, err, tt.wantErr)
+		}
+		if mr.recipient != tt.wantRecipient || mr.reader != tt.wantReader {
+			t.Errorf("%q: got message for %q and read by %q, want %q and %q", tt.userID, mr.recipient, mr.reader, tt.wantRecipient, tt.wantReader)
+		}
+		page, err := s.GetMessages(ctx, tt.userID, thread.ID.Hex(), "", 0)
+		if err != tt.wantErr {
+			t.Errorf("%q getting messages: got error %v, want %v", tt.userID, err, tt.wantErr)
+		}
+		if err == nil && page.Thread.Unread != tt.wantUnread {
+			t.Errorf("%q: got %d unread, want %d", tt.userID, page.Thread.Unread, tt.wantUnread)
+		}
+	}
+}
+
+func TestNormalizeMessageBody(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    string
+		wantErr error
+	}{
+		{body: "  Hello  ", want: "Hello"},
+		{body: " \n ", wantErr: ErrInvalidMessage},
+		{body: strings.Repeat("é", maxMessageLength), want: strings.Repeat("é", maxMessageLength)},
+		{body: strings.Repeat("é", maxMessageLength+1), wantErr: ErrInvalidMessage},
+	}
+	for _, tt := range tests {
+		got, err := normalizeMessageBody(tt.body)
+		if got != tt.want || err != tt.wantErr {
+			t.Errorf("%.20q: got %.20q and error %v, want %.20q and %v", tt.body, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMessagePreview(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: "Hello", want: "Hello"},
+		{body: strings.Repeat("é", messagePreviewLength), want: strings.Repeat("é", messagePreviewLength)},
+		{body: strings.Repeat("é", messagePreviewLength+1), want: strings.Repeat("é", messagePreviewLength) + "…"},
+	}
+	for _, tt := range tests {
+		if got := messagePreview(tt.body); got != tt.want {
+			t.Errorf("%.20q: got preview %.20q", tt.body, got)
+		}
+	}
+}
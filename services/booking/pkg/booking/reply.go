@@ -0,0 +1,41 @@
+package booking
+
+import (
+	"errors"
+
+	"contracts/pkg/contracts"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrApartmentsUnavailable = errors.New("apartments service is unavailable, try again later")
+
+// newReplyError wraps err into the envelope sent to other services, nil stays nil.
+func newReplyError(err error) *contracts.ReplyError {
+	switch {
+	case err == nil:
+		return nil
+	case err == ErrReservationNotFound, errors.Is(err, mongo.ErrNoDocuments):
+		return &contracts.ReplyError{Code: contracts.CodeNotFound, Message: err.Error()}
+	case err == ErrWrongIDFormat, err == ErrTooWideTimeSpan:
+		return &contracts.ReplyError{Code: contracts.CodeInvalidArgument, Message: err.Error()}
+	case err == ErrRequestingDatabase:
+		return &contracts.ReplyError{Code: contracts.CodeUnavailable, Message: err.Error(), Retryable: true}
+	default:
+		return &contracts.ReplyError{Code: contracts.CodeInternal, Message: err.Error()}
+	}
+}
+
+// fromApartmentsReply turns an error replied by the apartments service back into an error of this package.
+func fromApartmentsReply(e *contracts.ReplyError) error {
+	switch e.Code {
+	case contracts.CodeNotFound:
+		return ErrNoApartmentWithGivenID
+	case contracts.CodeInvalidArgument:
+		return ErrWrongIDFormat
+	case contracts.CodeUnavailable:
+		return ErrApartmentsUnavailable
+	default:
+		return e
+	}
+}
@@ -0,0 +1,54 @@
+package booking
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"contracts/pkg/contracts"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewReplyError(t *testing.T) {
+	noDocuments := fmt.Errorf("find reservation: %w", mongo.ErrNoDocuments)
+	tests := []struct {
+		err  error
+		want *contracts.ReplyError
+	}{
+		{err: nil, want: nil},
+		{err: ErrReservationNotFound, want: &contracts.ReplyError{Code: contracts.CodeNotFound, Message: ErrReservationNotFound.Error()}},
+		{err: noDocuments, want: &contracts.ReplyError{Code: contracts.CodeNotFound, Message: noDocuments.Error()}},
+		{err: ErrWrongIDFormat, want: &contracts.ReplyError{Code: contracts.CodeInvalidArgument, Message: ErrWrongIDFormat.Error()}},
+		{err: ErrTooWideTimeSpan, want: &contracts.ReplyError{Code: contracts.CodeInvalidArgument, Message: ErrTooWideTimeSpan.Error()}},
+		{
+			err:  ErrRequestingDatabase,
+			want: &contracts.ReplyError{Code: contracts.CodeUnavailable, Message: ErrRequestingDatabase.Error(), Retryable: true},
+		},
+		{err: errors.New("boom"), want: &contracts.ReplyError{Code: contracts.CodeInternal, Message: "boom"}},
+	}
+	for _, tt := range tests {
+		got := newReplyError(tt.err)
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("%v: got %+v, want %+v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFromApartmentsReply(t *testing.T) {
+	internal := &contracts.ReplyError{Code: contracts.CodeInternal, Message: "boom"}
+	tests := []struct {
+		reply *contracts.ReplyError
+		want  error
+	}{
+		{reply: &contracts.ReplyError{Code: contracts.CodeNotFound}, want: ErrNoApartmentWithGivenID},
+		{reply: &contracts.ReplyError{Code: contracts.CodeInvalidArgument}, want: ErrWrongIDFormat},
+		{reply: &contracts.ReplyError{Code: contracts.CodeUnavailable, Retryable: true}, want: ErrApartmentsUnavailable},
+		{reply: internal, want: internal},
+	}
+	for _, tt := range tests {
+		if got := fromApartmentsReply(tt.reply); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.reply.Code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const monthLayout = "2006-01"
+
+// MaxReportMonths limits the number of months a report covers.
+const MaxReportMonths = 24
+
+var ErrInvalidReportPeriod = errors.New("report period must be from and to months, from not after to")
+
+// MonthlyStats is a row of the reservations aggregation: the nights of an apartment in a month.
+type MonthlyStats struct {
+	ApartmentID   string  `bson:"apartmentId"`
+	Month         string  `bson:"month"`
+	BookedNights  int     `bson:"bookedNights"`
+	Revenue       float64 `bson:"revenue"`
+	Reservations  int     `bson:"reservations"`
+	Cancellations int     `bson:"cancellations"`
+}
+
+// Performance sums up the reservations of a period. ADR, the average daily rate, is the revenue per booked night.
+type Performance struct {
+	Month            string  `json:"month,omitempty"`
+	BookedNights     int     `json:"bookedNights"`
+	AvailableNights  int     `json:"availableNights"`
+	OccupancyRate    float64 `json:"occupancyRate"`
+	ADR              float64 `json:"adr"`
+	Revenue          float64 `json:"revenue"`
+	Reservations     int     `json:"reservations"`
+	Cancellations    int     `json:"cancellations"`
+	CancellationRate float64 `json:"cancellationRate"`
+}
+
+func (p *Performance) add(other Performance) {
+	p.BookedNights += other.BookedNights
+	p.AvailableNights += other.AvailableNights
+	p.Revenue += other.Revenue
+	p.Reservations += other.Reservations
+	p.Cancellations += other.Cancellations
+}
+
+func (p *Performance) computeRates() {
+	if p.AvailableNights > 0 {
+		p.OccupancyRate = float64(p.BookedNights) / float64(p.AvailableNights)
+	}
+	if p.BookedNights > 0 {
+		p.ADR = p.Revenue / float64(p.BookedNights)
+	}
+	if p.Reservations > 0 {
+		p.CancellationRate = float64(p.Cancellations) / float64(p.Reservations)
+	}
+}
+
+type ApartmentPerformance struct {
+	ApartmentID string        `json:"apartmentId"`
+	Title       string        `json:"title"`
+	Months      []Performance `json:"months"`
+	Total       Performance   `json:"total"`
+}
+
+// OwnerReport is the performance of every apartment of an owner, month by month, from From to To inclusive.
+type OwnerReport struct {
+	From       string                 `json:"from"`
+	To         string                 `json:"to"`
+	Apartments []ApartmentPerformance `json:"apartments"`
+	Total      Performance            `json:"total"`
+}
+
+// GetOwnerReport reports the performance of the apartments of the owner for the months from and to belong to.
+// The apartments are resolved through the apartments service.
+func (s *service) GetOwnerReport(ctx context.Context, ownerID string, from, to time.Time) (*OwnerReport, error) {
+	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return nil, ErrInvalidReportPeriod
+	}
+	months := make([]time.Time, 0)
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		months = append(months, month)
+	}
+	if len(months) > MaxReportMonths {
+		return nil, ErrTooWideTimeSpan
+	}
+
+	apartments, err := s.ar.GetApartmentsByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, s.apartmentsError(err)
+	}
+	report := &OwnerReport{From: from.Format(monthLayout), To: to.Format(monthLayout), Apartments: make([]ApartmentPerformance, 0)}
+	if len(apartments) == 0 {
+		return report, nil
+	}
+
+	apartmentIDs := make([]string, 0, len(apartments))
+	for _, apartment := range apartments {
+		apartmentIDs = append(apartmentIDs, apartment.ID)
+	}
+	// A day of margin on both sides covers every time zone, nights are then kept by their local month.
+	stats, err := s.r.GetMonthlyStats(ctx, apartmentIDs, from.AddDate(0, 0, -1), to.AddDate(0, 1, 1))
+	if err != nil {
+		return nil, err
+	}
+	byApartmentMonth := make(map[string]MonthlyStats, len(stats))
+	for _, row := range stats {
+		byApartmentMonth[row.ApartmentID+"/"+row.Month] = row
+	}
+
+	for _, apartment := range apartments {
+		performance := ApartmentPerformance{ApartmentID: apartment.ID, Title: apartment.Title, Months: make([]Performance, 0, len(months))}
+		for _, month := range months {
+			key := month.Format(monthLayout)
+			row := byApartmentMonth[apartment.ID+"/"+key]
+			monthly := Performance{
+				Month:           key,
+				BookedNights:    row.BookedNights,
+				AvailableNights: daysInMonth(month),
+				Revenue:         row.Revenue,
+				Reservations:    row.Reservations,
+				Cancellations:   row.Cancellations,
+			}
+			monthly.computeRates()
+			performance.Months = append(performance.Months, monthly)
+			performance.Total.add(monthly)
+		}
+		performance.Total.computeRates()
+		report.Total.add(performance.Total)
+		report.Apartments = append(report.Apartments, performance)
+	}
+	report.Total.computeRates()
+	return report, nil
+}
+
+func daysInMonth(month time.Time) int {
+	return time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,152 @@
+package booking
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// monthlyStats holds the rows of the reservations aggregation and keeps the period it was asked for.
+type monthlyStats struct {
+	Repository
+	rows     []MonthlyStats
+	from, to time.Time
+}
+
+func (r *monthlyStats) GetMonthlyStats(_ context.Context, _ []string, from, to time.Time) ([]MonthlyStats, error) {
+	r.from, r.to = from, to
+	return r.rows, nil
+}
+
+func TestGetOwnerReportPeriod(t *testing.T) {
+	tests := []struct {
+		from, to   time.Time
+		wantMonths int
+		wantErr    error
+	}{
+		{from: time.Date(2021, 2, 10, 0, 0, 0, 0, time.UTC), to: time.Date(2021, 2, 20, 0, 0, 0, 0, time.UTC), wantMonths: 1},
+		{from: time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), wantMonths: 4},
+		{from: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC), wantMonths: MaxReportMonths},
+		{from: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), wantErr: ErrTooWideTimeSpan},
+		{from: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), wantErr: ErrInvalidReportPeriod},
+	}
+	for _, tt := range tests {
+		period := tt.from.Format("2006-01-02") + ".." + tt.to.Format("2006-01-02")
+		r := &monthlyStats{}
+		s := NewService(r, apartments{owned: []Apartment{{ID: "a"}}}, nil, nil, zap.NewNop())
+		report, err := s.GetOwnerReport(context.Background(), "owner", tt.from, tt.to)
+		if err != tt.wantErr {
+			t.Errorf("%s: got error %v, want %v", period, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := len(report.Apartments[0].Months); got != tt.wantMonths {
+			t.Errorf("%s: got %d months, want %d", period, got, tt.wantMonths)
+		}
+		// The stats are asked with a day of margin around the months of the report.
+		from := time.Date(tt.from.Year(), tt.from.Month(), 0, 0, 0, 0, 0, time.UTC)
+		to := time.Date(tt.to.Year(), tt.to.Month()+1, 2, 0, 0, 0, 0, time.UTC)
+		if !r.from.Equal(from) || !r.to.Equal(to) {
+			t.Errorf("%s: stats asked from %s to %s, want %s to %s", period, r.from, r.to, from, to)
+		}
+	}
+}
+
+func TestGetOwnerReport(t *testing.T) {
+	r := &monthlyStats{rows: []MonthlyStats{
+		{ApartmentID: "a", Month: "2021-02", BookedNights: 14, Revenue: 1400, Reservations: 4, Cancellations: 1},
+		{ApartmentID: "a", Month: "2021-03", BookedNights: 31, Revenue: 2480, Reservations: 1},
+		{ApartmentID: "b", Month: "2021-03", BookedNights: 0, Revenue: 0, Reservations: 2, Cancellations: 2},
+		{ApartmentID: "other", Month: "2021-03", BookedNights: 10, Revenue: 1000, Reservations: 1},
+	}}
+	ar := apartments{owned: []Apartment{{ID: "a", Title: "A"}, {ID: "b", Title: "B"}}}
+	from, to := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	report, err := NewService(r, ar, nil, nil, zap.NewNop()).GetOwnerReport(context.Background(), "owner", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.From != "2021-02" || report.To != "2021-03" || len(report.Apartments) != 2 {
+		t.Fatalf("got report from %s to %s of %d apartments", report.From, report.To, len(report.Apartments))
+	}
+
+	tests := []struct {
+		name string
+		got  Performance
+		want Performance
+	}{
+		{
+			name: "a in February",
+			got:  report.Apartments[0].Months[0],
+			want: Performance{Month: "2021-02", BookedNights: 14, AvailableNights: 28, OccupancyRate: 0.5, ADR: 100, Revenue: 1400,
+				Reservations: 4, Cancellations: 1, CancellationRate: 0.25},
+		},
+		{
+			name: "a in total",
+			got:  report.Apartments[0].Total,
+			want: Performance{BookedNights: 45, AvailableNights: 59, OccupancyRate: 45.0 / 59, ADR: 3880.0 / 45, Revenue: 3880,
+				Reservations: 5, Cancellations: 1, CancellationRate: 0.2},
+		},
+		{
+			name: "b without bookings in February",
+			got:  report.Apartments[1].Months[0],
+			want: Performance{Month: "2021-02", AvailableNights: 28},
+		},
+		{
+			name: "b in March",
+			got:  report.Apartments[1].Months[1],
+			want: Performance{Month: "2021-03", AvailableNights: 31, Reservations: 2, Cancellations: 2, CancellationRate: 1},
+		},
+		{
+			name: "owner",
+			got:  report.Total,
+			want: Performance{BookedNights: 45, AvailableNights: 118, OccupancyRate: 45.0 / 118, ADR: 3880.0 / 45, Revenue: 3880,
+				Reservations: 7, Cancellations: 3, CancellationRate: 3.0 / 7},
+		},
+	}
+	for _, tt := range tests {
+		if !samePerformance(tt.got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	report, err = NewService(r, apartments{}, nil, nil, zap.NewNop()).GetOwnerReport(context.Background(), "owner", from, to)
+	if err != nil || len(report.Apartments) != 0 {
+		t.Errorf("without apartments: got %+v, %v", report, err)
+	}
+}
+
+func TestDaysInMonth(t *testing.T) {
+	tests := []struct {
+		month time.Time
+		want  int
+	}{
+		{month: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), want: 31},
+		{month: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), want: 28},
+		{month: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), want: 29},
+		{month: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC), want: 30},
+		{month: time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC), want: 31},
+	}
+	for _, tt := range tests {
+		if got := daysInMonth(tt.month); got != tt.want {
+			t.Errorf("%s: got %d days, want %d", tt.month.Format(monthLayout), got, tt.want)
+		}
+	}
+}
+
+// samePerformance compares performances with the rates rounded off.
+func samePerformance(got, want Performance) bool {
+	const epsilon = 1e-9
+	rates := [][2]float64{{got.OccupancyRate, want.OccupancyRate}, {got.ADR, want.ADR}, {got.CancellationRate, want.CancellationRate}}
+	for _, rate := range rates {
+		if math.Abs(rate[0]-rate[1]) > epsilon {
+			return false
+		}
+	}
+	got.OccupancyRate, got.ADR, got.CancellationRate = want.OccupancyRate, want.ADR, want.CancellationRate
+	return got == want
+}
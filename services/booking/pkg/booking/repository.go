@@ -13,6 +13,11 @@ import (
 
 const reservationCollectionName = "reservations"
 
+// reservedNightCollectionName is the collection of the nights held by reservations, see MakeReservation.
+const reservedNightCollectionName = "reservedNights"
+
+const dayMilliseconds = 24 * 3600 * 1000
+
 var ErrWrongIDFormat = errors.New("wrong id format")
 
 type MongoReservationsRepository struct {
@@ -35,8 +40,8 @@ func (r *MongoReservationsRepository) GetReservationsBetween(ctx context.Context
 		ctx,
 		bson.D{
 			primitive.E{Key: "apartmentID", Value: objectID},
-			primitive.E{Key: "start", Value: bson.D{{"$gte", start}}},
-			primitive.E{Key: "end", Value: bson.D{{"$lte", end}}},
+			primitive.E{Key: "start", Value: bson.D{{Key: "$gte", Value: start}}},
+			primitive.E{Key: "end", Value: bson.D{{Key: "$lte", Value: end}}},
 		}, opts)
 	if err != nil {
 		return nil, ErrRequestingDatabase
@@ -49,19 +54,273 @@ func (r *MongoReservationsRepository) GetReservationsBetween(ctx context.Context
 	return reservations, nil
 }
 
+// MakeReservation inserts the reservation unless a reservation of the apartment overlaps it, see
+// ErrReservationOverlaps. The nights of the reservation are reserved first: their ids are unique, so of
+// concurrent bookings of the same night only one inserts it.
 func (r *MongoReservationsRepository) MakeReservation(ctx context.Context, reservation *Reservation) (*Reservation, error) {
-	result, err := r.db.Collection(reservationCollectionName).InsertOne(
+	reservation.ID = primitive.NewObjectID()
+	if err := r.reserveNights(ctx, reservation); err != nil {
+		return nil, err
+	}
+	if _, err := r.db.Collection(reservationCollectionName).InsertOne(ctx, reservationDocument(reservation)); err != nil {
+		_ = r.releaseNights(ctx, reservation.ID)
+		return nil, ErrRequestingDatabase
+	}
+	return reservation, nil
+}
+
+// reservedNight is a night of an apartment held by a reservation, its id is the apartment id and the date.
+type reservedNight struct {
+	ID            string             `bson:"_id"`
+	ReservationID primitive.ObjectID `bson:"reservationId"`
+}
+
+// nightIDs are the ids of the nights of the reservation, dates in the time zone of the apartment.
+func nightIDs(reservation *Reservation) []string {
+	ids := make([]string, 0, reservation.Nights)
+	for night := 0; night < reservation.Nights; night++ {
+		ids = append(ids, reservation.ApartmentID+"/"+reservation.CheckIn.AddDate(0, 0, night).Format(dateLayout))
+	}
+	return ids
+}
+
+// reserveNights inserts the nights of the reservation, or none of them when one is already held. Nights
+// are only ever held by inserted reservations which are not cancelled, or for the time of inserting one.
+func (r *MongoReservationsRepository) reserveNights(ctx context.Context, reservation *Reservation) error {
+	nights := make([]interface{}, 0, reservation.Nights)
+	for _, id := range nightIDs(reservation) {
+		nights = append(nights, reservedNight{ID: id, ReservationID: reservation.ID})
+	}
+	_, err := r.db.Collection(reservedNightCollectionName).InsertMany(ctx, nights)
+	if err == nil {
+		return nil
+	}
+	// The insert stops at the first night held, the nights inserted before it are given back.
+	if releaseErr := r.releaseNights(ctx, reservation.ID); releaseErr != nil {
+		return releaseErr
+	}
+	if isDuplicateKey(err) {
+		return ErrReservationOverlaps
+	}
+	return ErrRequestingDatabase
+}
+
+// releaseNights makes the nights held by the reservation free to book.
+func (r *MongoReservationsRepository) releaseNights(ctx context.Context, reservationID primitive.ObjectID) error {
+	_, err := r.db.Collection(reservedNightCollectionName).DeleteMany(ctx, bson.D{{Key: "reservationId", Value: reservationID}})
+	if err != nil {
+		return ErrRequestingDatabase
+	}
+	return nil
+}
+
+// isDuplicateKey tells whether err is the error of a write breaking a unique index.
+func isDuplicateKey(err error) bool {
+	const duplicateKeyCode = 11000
+	var bulkWriteException mongo.BulkWriteException
+	if errors.As(err, &bulkWriteException) {
+		for _, writeErr := range bulkWriteException.WriteErrors {
+			if writeErr.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+	var writeException mongo.WriteException
+	if errors.As(err, &writeException) {
+		for _, writeErr := range writeException.WriteErrors {
+			if writeErr.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && commandErr.Code == duplicateKeyCode
+}
+
+// SaveReservation inserts or replaces the reservation with its ID and holds its nights unless it is cancelled.
+// Unlike MakeReservation it does not check for overlaps, the reservations saved must not overlap.
+func (r *MongoReservationsRepository) SaveReservation(ctx context.Context, reservation *Reservation) error {
+	_, err := r.db.Collection(reservationCollectionName).ReplaceOne(
 		ctx,
-		bson.M{
-			"apartmentId": reservation.ApartmentID,
-			"userId":      reservation.UserID,
-			"start":       reservation.Start,
-			"end":         reservation.End,
-			"created":     reservation.Created},
+		bson.D{{Key: "_id", Value: reservation.ID}},
+		reservationDocument(reservation),
+		options.Replace().SetUpsert(true),
 	)
 	if err != nil {
-		return nil, err
+		return ErrRequestingDatabase
 	}
-	reservation.ID = result.InsertedID.(primitive.ObjectID)
-	return reservation, nil
+	if reservation.Status == ReservationCancelled || reservation.Nights == 0 {
+		return r.releaseNights(ctx, reservation.ID)
+	}
+	models := make([]mongo.WriteModel, 0, reservation.Nights)
+	for _, id := range nightIDs(reservation) {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "_id", Value: id}}).
+			SetReplacement(reservedNight{ID: id, ReservationID: reservation.ID}).
+			SetUpsert(true))
+	}
+	if _, err = r.db.Collection(reservedNightCollectionName).BulkWrite(ctx, models); err != nil {
+		return ErrRequestingDatabase
+	}
+	return nil
+}
+
+func reservationDocument(reservation *Reservation) bson.M {
+	document := bson.M{
+		"_id":         reservation.ID,
+		"apartmentId": reservation.ApartmentID,
+		"userId":      reservation.UserID,
+		"start":       reservation.Start,
+		"end":         reservation.End,
+		"created":     reservation.Created,
+		"status":      reservation.Status,
+		"nights":      reservation.Nights,
+		"nightlyRate": reservation.NightlyRate,
+		"totalPrice":  reservation.TotalPrice,
+		"timeZone":    reservation.TimeZone,
+		"checkIn":     reservation.CheckIn,
+		"checkOut":    reservation.CheckOut,
+	}
+	if reservation.OwnerID != "" {
+		document["ownerId"] = reservation.OwnerID
+	}
+	if reservation.Cancelled != nil {
+		document["cancelled"] = reservation.Cancelled
+	}
+	return document
+}
+
+func (r *MongoReservationsRepository) GetReservationByID(ctx context.Context, reservationID string) (*Reservation, error) {
+	objectID, err := primitive.ObjectIDFromHex(reservationID)
+	if err != nil {
+		return nil, ErrWrongIDFormat
+	}
+	var reservation Reservation
+	err = r.db.Collection(reservationCollectionName).FindOne(ctx, bson.D{{Key: "_id", Value: objectID}}).Decode(&reservation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return &reservation, nil
+}
+
+// CancelReservation cancels the reservation if it still is confirmed and returns the cancelled reservation.
+// The nights of the reservation are released after, and again when cancelling it once more, so that a
+// cancellation whose release failed can be repeated.
+func (r *MongoReservationsRepository) CancelReservation(ctx context.Context, reservationID string, at time.Time) (*Reservation, error) {
+	objectID, err := primitive.ObjectIDFromHex(reservationID)
+	if err != nil {
+		return nil, ErrWrongIDFormat
+	}
+	var reservation Reservation
+	err = r.db.Collection(reservationCollectionName).FindOneAndUpdate(
+		ctx,
+		bson.D{{Key: "_id", Value: objectID}, {Key: "status", Value: bson.D{{Key: "$ne", Value: ReservationCancelled}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: ReservationCancelled}, {Key: "cancelled", Value: at}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reservation)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, ErrRequestingDatabase
+	}
+	if releaseErr := r.releaseNights(ctx, objectID); releaseErr != nil {
+		return nil, releaseErr
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReservationCancelled
+	}
+	return &reservation, nil
+}
+
+func (r *MongoReservationsRepository) CountReservationsEndingAfter(ctx context.Context, apartmentID string, t time.Time) (int64, error) {
+	count, err := r.db.Collection(reservationCollectionName).CountDocuments(
+		ctx,
+		bson.D{
+			primitive.E{Key: "apartmentId", Value: apartmentID},
+			primitive.E{Key: "end", Value: bson.D{{Key: "$gt", Value: TimeToTimestamp(t)}}},
+			primitive.E{Key: "status", Value: bson.D{{Key: "$ne", Value: ReservationCancelled}}},
+		})
+	if err != nil {
+		return 0, ErrRequestingDatabase
+	}
+	return count, nil
+}
+
+func (r *MongoReservationsRepository) GetApartmentIDsReservedBetween(ctx context.Context, start, end time.Time) ([]string, error) {
+	values, err := r.db.Collection(reservationCollectionName).Distinct(
+		ctx,
+		"apartmentId",
+		bson.D{
+			primitive.E{Key: "start", Value: bson.D{{Key: "$lt", Value: TimeToTimestamp(end)}}},
+			primitive.E{Key: "end", Value: bson.D{{Key: "$gt", Value: TimeToTimestamp(start)}}},
+			primitive.E{Key: "status", Value: bson.D{{Key: "$ne", Value: ReservationCancelled}}},
+		})
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	apartmentIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			apartmentIDs = append(apartmentIDs, id)
+		}
+	}
+	return apartmentIDs, nil
+}
+
+// GetMonthlyStats aggregates reservations of the apartments per apartment and month. Every reserved night
+// counts for the month it falls in, in the time zone of the apartment, only nights between from and to are
+// counted. Reservations and cancellations are counted in the month of their check-in.
+func (r *MongoReservationsRepository) GetMonthlyStats(ctx context.Context, apartmentIDs []string, from, to time.Time) ([]MonthlyStats, error) { //nolint:lll
+	confirmed := bson.D{{Key: "$eq", Value: bson.A{"$status", ReservationConfirmed}}}
+	cancelled := bson.D{{Key: "$eq", Value: bson.A{"$status", ReservationCancelled}}}
+	checkInNight := bson.D{{Key: "$eq", Value: bson.A{"$night", 0}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "apartmentId", Value: bson.D{{Key: "$in", Value: apartmentIDs}}},
+			{Key: "checkIn", Value: bson.D{{Key: "$lt", Value: to}}},
+			{Key: "checkOut", Value: bson.D{{Key: "$gt", Value: from}}},
+		}}},
+		{{Key: "$addFields", Value: bson.D{{Key: "night", Value: bson.D{{Key: "$range", Value: bson.A{0, "$nights"}}}}}}},
+		{{Key: "$unwind", Value: "$night"}},
+		// Noon of every night stays on the right day whatever the DST changes.
+		{{Key: "$addFields", Value: bson.D{{Key: "noon", Value: bson.D{{Key: "$add", Value: bson.A{
+			"$checkIn", bson.D{{Key: "$multiply", Value: bson.A{"$night", dayMilliseconds}}}, dayMilliseconds / 2,
+		}}}}}}},
+		{{Key: "$match", Value: bson.D{{Key: "noon", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "apartmentId", Value: "$apartmentId"},
+				{Key: "month", Value: bson.D{{Key: "$dateToString", Value: bson.D{
+					{Key: "format", Value: "%Y-%m"},
+					{Key: "date", Value: "$noon"},
+					{Key: "timezone", Value: "$timeZone"},
+				}}}},
+			}},
+			{Key: "bookedNights", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{confirmed, 1, 0}}}}}},
+			{Key: "revenue", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{confirmed, "$nightlyRate", 0}}}}}},
+			{Key: "reservations", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{checkInNight, 1, 0}}}}}},
+			{Key: "cancellations", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$and", Value: bson.A{checkInNight, cancelled}}}, 1, 0,
+			}}}}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "apartmentId", Value: "$_id.apartmentId"},
+			{Key: "month", Value: "$_id.month"},
+			{Key: "bookedNights", Value: 1},
+			{Key: "revenue", Value: 1},
+			{Key: "reservations", Value: 1},
+			{Key: "cancellations", Value: 1},
+		}}},
+	}
+	cursor, err := r.db.Collection(reservationCollectionName).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	stats := make([]MonthlyStats, 0)
+	if err = cursor.All(ctx, &stats); err != nil {
+		return nil, ErrRequestingDatabase
+	}
+	return stats, nil
 }
@@ -0,0 +1,63 @@
+package booking
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNightIDs(t *testing.T) {
+	paris := loadLocation(t, "Europe/Paris")
+	tests := []struct {
+		checkIn, checkOut time.Time
+		want              []string
+	}{
+		{
+			checkIn: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), checkOut: time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC),
+			want: []string{"a/2021-06-01"},
+		},
+		{
+			checkIn: time.Date(2021, 2, 27, 0, 0, 0, 0, time.UTC), checkOut: time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC),
+			want: []string{"a/2021-02-27", "a/2021-02-28", "a/2021-03-01"},
+		},
+		// Nights are calendar dates of the apartment, whatever the length of the night summer time starts or ends.
+		{
+			checkIn: time.Date(2021, 3, 27, 0, 0, 0, 0, paris), checkOut: time.Date(2021, 3, 30, 0, 0, 0, 0, paris),
+			want: []string{"a/2021-03-27", "a/2021-03-28", "a/2021-03-29"},
+		},
+		{
+			checkIn: time.Date(2021, 10, 30, 0, 0, 0, 0, paris), checkOut: time.Date(2021, 11, 1, 0, 0, 0, 0, paris),
+			want: []string{"a/2021-10-30", "a/2021-10-31"},
+		},
+	}
+	for _, tt := range tests {
+		reservation := NewReservation(&Apartment{ID: "a"}, "guest", tt.checkIn, tt.checkOut)
+		if got := nightIDs(reservation); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s to %s: got %v, want %v", tt.checkIn, tt.checkOut, got, tt.want)
+		}
+	}
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil},
+		{err: errors.New("connection refused")},
+		{err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 11000}}}}, want: true},
+		{err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 121}}}}},
+		{err: mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}, want: true},
+		{err: mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 121}}}},
+		{err: mongo.CommandError{Code: 11000}, want: true},
+		{err: mongo.CommandError{Code: 50}},
+	}
+	for _, tt := range tests {
+		if got := isDuplicateKey(tt.err); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
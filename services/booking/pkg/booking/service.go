@@ -5,6 +5,8 @@ import (
 	"errors"
 	"time"
 
+	"contracts/pkg/contracts"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
@@ -17,58 +19,108 @@ var ErrTooWideTimeSpan = errors.New("too wide time span")
 var ErrReservationDurationLimitExceeded = errors.New("reservation duration limit exceeded")
 var ErrCouldNotGetApartment = errors.New("error with requesting apartment")
 var ErrNoApartmentWithGivenID = errors.New("no apartment with given id")
+var ErrApartmentNotPublished = errors.New("apartment is not published")
+var ErrInvalidReservationDates = errors.New("reservation must end at least a day after it starts")
+var ErrReservationNotFound = errors.New("reservation not found")
+var ErrNotReservationGuest = errors.New("only the guest can cancel the reservation")
+var ErrReservationCancelled = errors.New("reservation is already cancelled")
+var ErrReservationOverlaps = errors.New("apartment is already reserved for some of these nights")
 
 type City string
 
+type ReservationStatus string
+
+const (
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationCancelled ReservationStatus = "cancelled"
+)
+
 type Reservation struct {
 	ID          primitive.ObjectID  `json:"_id" bson:"_id"`
-	ApartmentID string              `json:"apartmentId"`
-	UserID      string              `json:"userId"`
-	Start       primitive.Timestamp `json:"start"`
-	End         primitive.Timestamp `json:"end"`
-	Created     primitive.Timestamp `json:"created"`
+	ApartmentID string              `json:"apartmentId" bson:"apartmentId"`
+	UserID      string              `json:"userId" bson:"userId"`
+	Start       primitive.Timestamp `json:"start" bson:"start"`
+	End         primitive.Timestamp `json:"end" bson:"end"`
+	Created     primitive.Timestamp `json:"created" bson:"created"`
+	Status      ReservationStatus   `json:"status" bson:"status"`
+	Nights      int                 `json:"nights" bson:"nights"`
+	NightlyRate float64             `json:"nightlyRate" bson:"nightlyRate"`
+	TotalPrice  float64             `json:"totalPrice" bson:"totalPrice"`
+	TimeZone    string              `json:"timeZone" bson:"timeZone"`
+	// OwnerID is the owner of the apartment when it was reserved, it is empty for older reservations.
+	OwnerID string `json:"ownerId,omitempty" bson:"ownerId,omitempty"`
+	// CheckIn and CheckOut repeat Start and End as dates, aggregation date operators do not work on timestamps.
+	CheckIn   time.Time  `json:"checkIn" bson:"checkIn"`
+	CheckOut  time.Time  `json:"checkOut" bson:"checkOut"`
+	Cancelled *time.Time `json:"cancelled,omitempty" bson:"cancelled,omitempty"`
 }
 
-func NewReservation(apartmentID, userID string, start, end time.Time) *Reservation {
+// NewReservation makes a confirmed reservation of the apartment at its current price, start and end
+// are midnights in the apartment time zone.
+func NewReservation(apartment *Apartment, userID string, start, end time.Time) *Reservation {
+	nights := nightsBetween(start, end)
 	return &Reservation{
-		ApartmentID: apartmentID,
+		ApartmentID: apartment.ID,
 		UserID:      userID,
+		OwnerID:     apartment.Owner,
 		Start:       TimeToTimestamp(start),
 		End:         TimeToTimestamp(end),
 		Created:     TimeToTimestamp(time.Now()),
+		Status:      ReservationConfirmed,
+		Nights:      nights,
+		NightlyRate: apartment.Price,
+		TotalPrice:  apartment.Price * float64(nights),
+		TimeZone:    start.Location().String(),
+		CheckIn:     start,
+		CheckOut:    end,
 	}
 }
 
-type Apartment struct {
-	ID      string `json:"_id"`
-	Title   string `json:"title"`
-	Address string `json:"address"`
-	Owner   string `json:"owner"`
-	City    string `json:"city"`
-}
+// ApartmentStatusPublished is the only status of apartments that can be booked.
+const ApartmentStatusPublished = "published"
+
+// Apartment is an apartment as the apartments service replies it.
+type Apartment = contracts.Apartment
 
 type Service interface {
 	GetReservations(ctx context.Context, apartmentID string, start, end time.Time) (out []Reservation, err error)
 	BookApartment(ctx context.Context, userID, apartmentID string, start, end time.Time) (out *Reservation, err error)
+	HasFutureReservations(ctx context.Context, apartmentID string) (bool, error)
+	GetBusyApartmentIDs(ctx context.Context, start, end time.Time) ([]string, error)
+	CancelReservation(ctx context.Context, userID, reservationID string) (*Reservation, error)
+	GetOwnerReport(ctx context.Context, ownerID string, from, to time.Time) (*OwnerReport, error)
+	StartThread(ctx context.Context, userID, apartmentID, reservationID, body string) (*ThreadMessages, error)
+	GetThreads(ctx context.Context, userID string) (threads []Thread, unread int, err error)
+	GetMessages(ctx context.Context, userID, threadID, before string, limit int) (*ThreadMessages, error)
+	PostMessage(ctx context.Context, userID, threadID, body string) (*Message, error)
+	MarkThreadRead(ctx context.Context, userID, threadID string) error
 }
 
 type Repository interface {
 	GetReservationsBetween(ctx context.Context, apartmentID string, start, end time.Time) ([]Reservation, error)
 	MakeReservation(ctx context.Context, reservation *Reservation) (*Reservation, error)
+	CountReservationsEndingAfter(ctx context.Context, apartmentID string, t time.Time) (int64, error)
+	GetApartmentIDsReservedBetween(ctx context.Context, start, end time.Time) ([]string, error)
+	GetReservationByID(ctx context.Context, reservationID string) (*Reservation, error)
+	CancelReservation(ctx context.Context, reservationID string, at time.Time) (*Reservation, error)
+	GetMonthlyStats(ctx context.Context, apartmentIDs []string, from, to time.Time) ([]MonthlyStats, error)
 }
 
 type ApartmentsRepository interface {
-	GetApartmentByID(ctx context.Context, apartmentID string) (*Apartment, error)
+	GetApartmentByID(ctx context.Context, userID, apartmentID string) (*Apartment, error)
+	GetApartmentsByOwner(ctx context.Context, ownerID string) ([]Apartment, error)
 }
 
 type service struct {
 	r      Repository
 	ar     ApartmentsRepository
+	mr     MessagingRepository
+	events EventPublisher
 	logger *zap.Logger
 }
 
-func NewService(r Repository, ar ApartmentsRepository, logger *zap.Logger) Service {
-	return &service{r: r, ar: ar, logger: logger}
+func NewService(r Repository, ar ApartmentsRepository, mr MessagingRepository, events EventPublisher, logger *zap.Logger) Service {
+	return &service{r: r, ar: ar, mr: mr, events: events, logger: logger}
 }
 
 func (s *service) GetReservations(ctx context.Context, apartmentID string, start, end time.Time) ([]Reservation, error) {
@@ -83,13 +135,60 @@ func (s *service) BookApartment(ctx context.Context, userID, apartmentID string,
 		return nil, ErrReservationDurationLimitExceeded
 	}
 
-	_, err := s.ar.GetApartmentByID(ctx, apartmentID)
+	apartment, err := s.ar.GetApartmentByID(ctx, userID, apartmentID)
+	if err != nil {
+		return nil, s.apartmentsError(err)
+	}
+	if apartment.Status != ApartmentStatusPublished {
+		return nil, ErrApartmentNotPublished
+	}
+
+	loc := apartmentLocation(apartment)
+	start, end = startOfDay(start, loc), startOfDay(end, loc)
+	if nightsBetween(start, end) < 1 {
+		return nil, ErrInvalidReservationDates
+	}
+	return s.r.MakeReservation(ctx, NewReservation(apartment, userID, start, end))
+}
+
+// CancelReservation cancels a confirmed reservation on behalf of the guest who made it.
+func (s *service) CancelReservation(ctx context.Context, userID, reservationID string) (*Reservation, error) {
+	reservation, err := s.r.GetReservationByID(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.UserID != userID {
+		return nil, ErrNotReservationGuest
+	}
+	// Cancelled reservations are left to the repository, which answers ErrReservationCancelled once it
+	// made sure their nights are released.
+	return s.r.CancelReservation(ctx, reservationID, time.Now())
+}
+
+// apartmentsError keeps the errors of the apartments service a client can act upon and hides the others.
+func (s *service) apartmentsError(err error) error {
+	switch err {
+	case ErrNoApartmentWithGivenID, ErrWrongIDFormat, ErrApartmentsUnavailable:
+		return err
+	}
+	s.logger.Error("error getting apartment from apartments service", zap.Error(err))
+	return ErrCouldNotGetApartment
+}
+
+func (s *service) HasFutureReservations(ctx context.Context, apartmentID string) (bool, error) {
+	count, err := s.r.CountReservationsEndingAfter(ctx, apartmentID, time.Now())
 	if err != nil {
-		s.logger.Error("error getting apartment from apartments service", zap.Error(err))
-		return nil, ErrCouldNotGetApartment
+		return false, err
 	}
+	return count > 0, nil
+}
 
-	return s.r.MakeReservation(ctx, NewReservation(apartmentID, userID, start, end))
+// GetBusyApartmentIDs returns ids of the apartments with reservations overlapping the given window.
+func (s *service) GetBusyApartmentIDs(ctx context.Context, start, end time.Time) ([]string, error) {
+	if end.Sub(start) > contracts.MaxBusyApartmentsWindow {
+		return nil, ErrTooWideTimeSpan
+	}
+	return s.r.GetApartmentIDsReservedBetween(ctx, start, end)
 }
 
 func TimeToTimestamp(t time.Time) primitive.Timestamp {
@@ -0,0 +1,133 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reservations keeps the reservations made in memory, it finds and cancels the ones it holds by id.
+type reservations struct {
+	Repository
+	made      []Reservation
+	byID      map[string]Reservation
+	cancelled []string
+}
+
+func (r *reservations) MakeReservation(_ context.Context, reservation *Reservation) (*Reservation, error) {
+	r.made = append(r.made, *reservation)
+	return reservation, nil
+}
+
+func (r *reservations) GetReservationByID(_ context.Context, reservationID string) (*Reservation, error) {
+	reservation, ok := r.byID[reservationID]
+	if !ok {
+		return nil, ErrReservationNotFound
+	}
+	return &reservation, nil
+}
+
+func (r *reservations) CancelReservation(_ context.Context, reservationID string, _ time.Time) (*Reservation, error) {
+	r.cancelled = append(r.cancelled, reservationID)
+	reservation := r.byID[reservationID]
+	if reservation.Status == ReservationCancelled {
+		return nil, ErrReservationCancelled
+	}
+	reservation.Status = ReservationCancelled
+	return &reservation, nil
+}
+
+// apartments answers for the apartments it holds by id, and with err for the others.
+type apartments struct {
+	byID  map[string]Apartment
+	owned []Apartment
+	err   error
+}
+
+func (a apartments) GetApartmentByID(_ context.Context, _, apartmentID string) (*Apartment, error) {
+	apartment, ok := a.byID[apartmentID]
+	if !ok {
+		return nil, a.err
+	}
+	return &apartment, nil
+}
+
+func (a apartments) GetApartmentsByOwner(context.Context, string) ([]Apartment, error) {
+	return a.owned, a.err
+}
+
+func TestBookApartmentStatus(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		status  string
+		wantErr error
+	}{
+		{status: ApartmentStatusPublished},
+		{status: "draft", wantErr: ErrApartmentNotPublished},
+		{status: "suspended", wantErr: ErrApartmentNotPublished},
+		{status: "", wantErr: ErrApartmentNotPublished},
+	}
+	for _, tt := range tests {
+		r := &reservations{}
+		ar := apartments{byID: map[string]Apartment{"a": {ID: "a", Status: tt.status}}}
+		_, err := NewService(r, ar, nil, nil, zap.NewNop()).BookApartment(context.Background(), "guest", "a", start, start.AddDate(0, 0, 2))
+		if err != tt.wantErr {
+			t.Errorf("%q: got error %v, want %v", tt.status, err, tt.wantErr)
+		}
+		if booked := len(r.made) == 1; booked != (tt.wantErr == nil) {
+			t.Errorf("%q: %d reservations made", tt.status, len(r.made))
+		}
+	}
+
+	s := NewService(&reservations{}, apartments{err: errors.New("apartment not found")}, nil, nil, zap.NewNop())
+	if _, err := s.BookApartment(context.Background(), "guest", "a", start, start.AddDate(0, 0, 2)); err != ErrCouldNotGetApartment {
+		t.Errorf("missing apartment: got error %v, want %v", err, ErrCouldNotGetApartment)
+	}
+}
+
+func TestBookApartmentDates(t *testing.T) {
+	lisbon := loadLocation(t, "Europe/Lisbon")
+	r := &reservations{}
+	ar := apartments{byID: map[string]Apartment{"a": {ID: "a", Status: ApartmentStatusPublished, TimeZone: "Europe/Lisbon"}}}
+	late := time.Date(2021, 6, 1, 23, 30, 0, 0, time.FixedZone("", -5*3600))
+	s := NewService(r, ar, nil, nil, zap.NewNop())
+	if _, err := s.BookApartment(context.Background(), "guest", "a", late, late.AddDate(0, 0, 2)); err != nil {
+		t.Fatal(err)
+	}
+	start, end := time.Date(2021, 6, 1, 0, 0, 0, 0, lisbon), time.Date(2021, 6, 3, 0, 0, 0, 0, lisbon)
+	if got := r.made[0]; got.Start != TimeToTimestamp(start) || got.End != TimeToTimestamp(end) {
+		t.Errorf("got reservation from %d to %d, want midnights in Lisbon %d and %d", got.Start.T, got.End.T, start.Unix(), end.Unix())
+	}
+}
+
+func TestCancelReservation(t *testing.T) {
+	r := &reservations{byID: map[string]Reservation{
+		"confirmed": {UserID: "guest", Status: ReservationConfirmed},
+		"cancelled": {UserID: "guest", Status: ReservationCancelled},
+	}}
+	tests := []struct {
+		userID        string
+		reservationID string
+		wantErr       error
+		wantCancelled bool
+	}{
+		{userID: "guest", reservationID: "confirmed", wantCancelled: true},
+		// Cancelling again reaches the repository, which releases the nights a failed cancellation kept held.
+		{userID: "guest", reservationID: "cancelled", wantErr: ErrReservationCancelled, wantCancelled: true},
+		{userID: "intruder", reservationID: "confirmed", wantErr: ErrNotReservationGuest},
+		{userID: "guest", reservationID: "missing", wantErr: ErrReservationNotFound},
+	}
+	for _, tt := range tests {
+		r.cancelled = nil
+		_, err := NewService(r, apartments{}, nil, nil, zap.NewNop()).CancelReservation(context.Background(), tt.userID, tt.reservationID)
+		if err != tt.wantErr {
+			t.Errorf("%s by %s: got error %v, want %v", tt.reservationID, tt.userID, err, tt.wantErr)
+		}
+		if cancelled := len(r.cancelled) == 1; cancelled != tt.wantCancelled {
+			t.Errorf("%s by %s: cancelled %v in the repository, want %v", tt.reservationID, tt.userID, cancelled, tt.wantCancelled)
+		}
+	}
+}
@@ -3,29 +3,46 @@ package booking
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"time"
+
+	"contracts/pkg/auth"
+	"contracts/pkg/contracts"
+	nats_tracing "contracts/pkg/nats-tracing"
+	"telemetry/pkg/telemetry"
 
 	"github.com/go-kit/kit/circuitbreaker"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
 	kithttp "github.com/go-kit/kit/transport/http"
+	kitnats "github.com/go-kit/kit/transport/nats"
 	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
 	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/trace"
 
 	"net/http"
 )
 
-func MakeHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+const queueName = "booking"
+
+// MakeHTTPHandler serves the service over HTTP, every request is traced by a server span named after its route.
+// The users of requests are decoded from their bearer tokens by users.
+func MakeHTTPHandler(s Service, logger kitlog.Logger, tracer trace.TracerProvider, m EndpointMetrics, users *auth.Verifier) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		kithttp.ServerErrorEncoder(encodeError),
+		telemetry.HTTPServerTrace(tracer),
 	}
 
 	getApartmentsEndpoint := makeGetApartmentsEndpoint(s)
 	getApartmentsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getApartmentsEndpoint)
+	getApartmentsEndpoint = InstrumentingMiddleware(m, "GetReservations", TransportHTTP)(getApartmentsEndpoint)
 	getReservationsHandler := kithttp.NewServer(getApartmentsEndpoint, decodeGetApartmentsRequest, encodeResponse, opts...)
 
 	bookApartmentEndpoint := makeBookApartmentEndpoint(s)
 	bookApartmentEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(bookApartmentEndpoint)
+	bookApartmentEndpoint = InstrumentingMiddleware(m, "BookApartment", TransportHTTP)(bookApartmentEndpoint)
 	bookApartmentHandler := kithttp.NewServer(
 		bookApartmentEndpoint,
 		DefaultRequestDecoder(decodeBookApartmentRequest),
@@ -33,12 +50,59 @@ func MakeHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
 		opts...,
 	)
 
+	cancelReservationEndpoint := makeCancelReservationEndpoint(s)
+	cancelReservationEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(cancelReservationEndpoint)
+	cancelReservationEndpoint = InstrumentingMiddleware(m, "CancelReservation", TransportHTTP)(cancelReservationEndpoint)
+	cancelReservationHandler := kithttp.NewServer(
+		cancelReservationEndpoint,
+		DefaultRequestDecoder(decodeCancelReservationRequest),
+		encodeResponse,
+		opts...,
+	)
+
+	ownerReportEndpoint := makeGetOwnerReportEndpoint(s)
+	ownerReportEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(ownerReportEndpoint)
+	ownerReportEndpoint = InstrumentingMiddleware(m, "GetOwnerReport", TransportHTTP)(ownerReportEndpoint)
+	ownerReportHandler := kithttp.NewServer(ownerReportEndpoint, DefaultRequestDecoder(decodeOwnerReportRequest), encodeResponse, opts...)
+
+	startThreadEndpoint := makeStartThreadEndpoint(s)
+	startThreadEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(startThreadEndpoint)
+	startThreadEndpoint = InstrumentingMiddleware(m, "StartThread", TransportHTTP)(startThreadEndpoint)
+	startThreadHandler := kithttp.NewServer(startThreadEndpoint, DefaultRequestDecoder(decodeStartThreadRequest), encodeResponse, opts...)
+
+	getThreadsEndpoint := makeGetThreadsEndpoint(s)
+	getThreadsEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getThreadsEndpoint)
+	getThreadsEndpoint = InstrumentingMiddleware(m, "GetThreads", TransportHTTP)(getThreadsEndpoint)
+	getThreadsHandler := kithttp.NewServer(getThreadsEndpoint, DefaultRequestDecoder(decodeThreadRequest), encodeResponse, opts...)
+
+	getMessagesEndpoint := makeGetMessagesEndpoint(s)
+	getMessagesEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(getMessagesEndpoint)
+	getMessagesEndpoint = InstrumentingMiddleware(m, "GetMessages", TransportHTTP)(getMessagesEndpoint)
+	getMessagesHandler := kithttp.NewServer(getMessagesEndpoint, DefaultRequestDecoder(decodeGetMessagesRequest), encodeResponse, opts...)
+
+	postMessageEndpoint := makePostMessageEndpoint(s)
+	postMessageEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(postMessageEndpoint)
+	postMessageEndpoint = InstrumentingMiddleware(m, "PostMessage", TransportHTTP)(postMessageEndpoint)
+	postMessageHandler := kithttp.NewServer(postMessageEndpoint, DefaultRequestDecoder(decodePostMessageRequest), encodeResponse, opts...)
+
+	markThreadReadEndpoint := makeMarkThreadReadEndpoint(s)
+	markThreadReadEndpoint = circuitbreaker.Gobreaker(gobreaker.NewCircuitBreaker(gobreaker.Settings{}))(markThreadReadEndpoint)
+	markThreadReadEndpoint = InstrumentingMiddleware(m, "MarkThreadRead", TransportHTTP)(markThreadReadEndpoint)
+	markThreadReadHandler := kithttp.NewServer(markThreadReadEndpoint, DefaultRequestDecoder(decodeThreadRequest), encodeResponse, opts...)
+
 	r := mux.NewRouter()
 
 	r.Handle("/reservations", getReservationsHandler).Methods("GET")
 	r.Handle("/reservations", bookApartmentHandler).Methods("POST")
+	r.Handle("/reservations/{id}/cancel", cancelReservationHandler).Methods("POST")
+	r.Handle("/reports/occupancy", ownerReportHandler).Methods("GET")
+	r.Handle("/threads", getThreadsHandler).Methods("GET")
+	r.Handle("/threads", startThreadHandler).Methods("POST")
+	r.Handle("/threads/{id}/messages", getMessagesHandler).Methods("GET")
+	r.Handle("/threads/{id}/messages", postMessageHandler).Methods("POST")
+	r.Handle("/threads/{id}/read", markThreadReadHandler).Methods("POST")
 
-	return r
+	return users.Handler(r)
 }
 
 func decodeGetApartmentsRequest(_ context.Context, r *http.Request) (interface{}, error) {
@@ -57,6 +121,64 @@ func decodeBookApartmentRequest(r *http.Request) (UserClaimable, error) {
 	return &req, nil
 }
 
+func decodeCancelReservationRequest(r *http.Request) (UserClaimable, error) {
+	return &cancelReservationRequest{ReservationID: mux.Vars(r)["id"]}, nil
+}
+
+// decodeOwnerReportRequest reads the from and to months, formatted as 2006-01. The report covers the last
+// twelve months by default.
+func decodeOwnerReportRequest(r *http.Request) (UserClaimable, error) {
+	now := time.Now().UTC()
+	req := &ownerReportRequest{From: now.AddDate(0, -11, 0), To: now}
+	q := r.URL.Query()
+	for key, month := range map[string]*time.Time{"from": &req.From, "to": &req.To} {
+		value := q.Get(key)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(monthLayout, value)
+		if err != nil {
+			return nil, ErrInvalidReportPeriod
+		}
+		*month = parsed
+	}
+	return req, nil
+}
+
+func decodeStartThreadRequest(r *http.Request) (UserClaimable, error) {
+	var req startThreadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func decodeThreadRequest(r *http.Request) (UserClaimable, error) {
+	return &threadRequest{ThreadID: mux.Vars(r)["id"]}, nil
+}
+
+// decodeGetMessagesRequest reads the before message id and the limit of a page of messages.
+func decodeGetMessagesRequest(r *http.Request) (UserClaimable, error) {
+	q := r.URL.Query()
+	req := &threadRequest{ThreadID: mux.Vars(r)["id"], Before: q.Get("before")}
+	if limit := q.Get("limit"); limit != "" {
+		var err error
+		if req.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, ErrInvalidLimit
+		}
+	}
+	return req, nil
+}
+
+func decodePostMessageRequest(r *http.Request) (UserClaimable, error) {
+	var req threadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, err
+	}
+	req.ThreadID = mux.Vars(r)["id"]
+	return &req, nil
+}
+
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if e, ok := response.(Errorer); ok && e.Error() != nil {
 		encodeError(ctx, e.Error(), w)
@@ -68,24 +190,40 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	switch err {
-	case ErrWrongIDFormat:
-		w.WriteHeader(http.StatusBadRequest)
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
-	}
+	w.WriteHeader(statusOf(err))
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
 		"error": err.Error(),
 	})
 }
 
+// statusOf is the HTTP status code requests failing with err are answered with.
+func statusOf(err error) int {
+	switch err {
+	case ErrWrongIDFormat, ErrInvalidReservationDates, ErrInvalidReportPeriod, ErrTooWideTimeSpan, ErrReservationDurationLimitExceeded,
+		ErrInvalidMessage, ErrInvalidLimit, ErrOwnApartmentThread:
+		return http.StatusBadRequest
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrNotReservationGuest:
+		return http.StatusForbidden
+	case ErrReservationNotFound, ErrThreadNotFound, ErrNoApartmentWithGivenID:
+		return http.StatusNotFound
+	case ErrApartmentNotPublished, ErrReservationCancelled, ErrReservationOverlaps:
+		return http.StatusConflict
+	case ErrApartmentsUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 type UserClaimable interface {
 	SetUserClaim(claim *UserClaim)
 }
 
 func DefaultRequestDecoder(decoder func(r *http.Request) (UserClaimable, error)) func(_ context.Context, r *http.Request) (interface{}, error) { //nolint:lll
 	return func(_ context.Context, r *http.Request) (interface{}, error) {
-		userClaim, err := GetUserClaimFromRequest(r)
+		userClaim, err := auth.UserFromRequest(r)
 		if err != nil {
 			return nil, err
 		}
@@ -98,3 +236,34 @@ func DefaultRequestDecoder(decoder func(r *http.Request) (UserClaimable, error))
 		return request, nil
 	}
 }
+
+func MakeNatsHandler(s Service, nc *nats.Conn, tracer trace.TracerProvider, m EndpointMetrics, traceOptions ...nats_tracing.TracerOption) {
+	traceOption := nats_tracing.Options(traceOptions...)
+	hasFutureReservationsEndpoint := nats_tracing.TraceEndpointErrors(makeHasFutureReservationsEndpoint(s))
+	hasFutureReservationsEndpoint = InstrumentingMiddleware(m, "HasFutureReservations", TransportNATS)(hasFutureReservationsEndpoint)
+	subscriber := kitnats.NewSubscriber(
+		hasFutureReservationsEndpoint,
+		contracts.DecodeHasFutureReservationsRequest,
+		contracts.EncodeResponse,
+		nats_tracing.NATSSubscriberTrace(tracer, traceOption, nats_tracing.SetName("check future reservations")),
+		kitnats.SubscriberBefore(contracts.ContentTypeToContext),
+	)
+	_, err := nc.QueueSubscribe(contracts.SubjectHasFutureReservations, queueName, subscriber.ServeMsg(nc))
+	if err != nil {
+		panic(err)
+	}
+
+	busyApartmentsEndpoint := nats_tracing.TraceEndpointErrors(makeGetBusyApartmentsEndpoint(s))
+	busyApartmentsEndpoint = InstrumentingMiddleware(m, "GetBusyApartmentIDs", TransportNATS)(busyApartmentsEndpoint)
+	busyApartmentsSubscriber := kitnats.NewSubscriber(
+		busyApartmentsEndpoint,
+		contracts.DecodeGetBusyApartmentsRequest,
+		contracts.EncodeResponse,
+		nats_tracing.NATSSubscriberTrace(tracer, traceOption, nats_tracing.SetName("get busy apartments")),
+		kitnats.SubscriberBefore(contracts.ContentTypeToContext),
+	)
+	_, err = nc.QueueSubscribe(contracts.SubjectGetBusyApartments, queueName, busyApartmentsSubscriber.ServeMsg(nc))
+	if err != nil {
+		panic(err)
+	}
+}
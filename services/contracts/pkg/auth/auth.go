@@ -0,0 +1,103 @@
+// Package auth reads the users of the JWTs issued by the users service. The services share it so that they
+// agree on the claims and on the secret tokens are signed with.
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SecretEnv is the environment variable the services read the token secret from by default. The users service
+// reads it too, it overrides its jwt.secret property. The secret is base64 encoded, as the users service expects.
+const SecretEnv = "JWT_SECRET"
+
+// AdminRole is the role claim of users who moderate apartments.
+const AdminRole = "admin"
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+var ErrInvalidSecret = errors.New("the secret to verify tokens with must be base64 encoded and not empty")
+
+type UserClaim struct {
+	jwt.StandardClaims
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+}
+
+func (c *UserClaim) IsAdmin() bool {
+	return c.Role == AdminRole
+}
+
+// Verifier decodes the users of tokens signed with the secret shared with the users service.
+type Verifier struct {
+	secret []byte
+}
+
+// NewVerifier verifies tokens with the key secret encodes in base64.
+func NewVerifier(secret string) (*Verifier, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return &Verifier{secret: key}, nil
+}
+
+// Decode returns the user of the token, tokens which are not signed with the secret by HMAC or carry no user id
+// are ErrUnauthorized.
+func (v *Verifier) Decode(token string) (*UserClaim, error) {
+	claim := &UserClaim{}
+	_, err := jwt.ParseWithClaims(token, claim, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnauthorized
+		}
+		return v.secret, nil
+	})
+	if err != nil || claim.ID == "" {
+		return nil, ErrUnauthorized
+	}
+	return claim, nil
+}
+
+type contextKey struct{}
+
+// requestUser is the outcome of decoding the Authorization header of a request.
+type requestUser struct {
+	claim *UserClaim
+	err   error
+}
+
+// Handler decodes the bearer token of requests before passing them to next, see UserFromRequest.
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user requestUser
+		if header := r.Header.Get("Authorization"); header != "" {
+			user.claim, user.err = v.Decode(strings.TrimPrefix(header, "Bearer "))
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, user)))
+	})
+}
+
+// UserFromRequest returns the user of a request passed through Handler, anonymous requests are ErrUnauthorized.
+func UserFromRequest(r *http.Request) (*UserClaim, error) {
+	claim, err := OptionalUserFromRequest(r)
+	if err == nil && claim == nil {
+		return nil, ErrUnauthorized
+	}
+	return claim, err
+}
+
+// OptionalUserFromRequest returns nil for anonymous requests, a present but invalid token is still ErrUnauthorized.
+// Requests which did not pass through Handler are refused.
+func OptionalUserFromRequest(r *http.Request) (*UserClaim, error) {
+	user, ok := r.Context().Value(contextKey{}).(requestUser)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	return user.claim, user.err
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claim UserClaim) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claim).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestNewVerifier(t *testing.T) {
+	for _, secret := range []string{"", "not base64!"} {
+		if _, err := NewVerifier(secret); err != ErrInvalidSecret {
+			t.Errorf("%q: got error %v, want %v", secret, err, ErrInvalidSecret)
+		}
+	}
+}
+
+func TestVerifierHandler(t *testing.T) {
+	key := []byte("secret")
+	verifier, err := NewVerifier(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		authorization string
+		wantID        string
+		wantErr       error
+		wantOptional  error
+	}{
+		{name: "anonymous", wantErr: ErrUnauthorized},
+		{name: "user", authorization: "Bearer " + sign(t, jwt.SigningMethodHS512, key, UserClaim{ID: "1"}), wantID: "1"},
+		{
+			name:          "other secret",
+			authorization: "Bearer " + sign(t, jwt.SigningMethodHS512, []byte("other"), UserClaim{ID: "1"}),
+			wantErr:       ErrUnauthorized,
+			wantOptional:  ErrUnauthorized,
+		},
+		{
+			name:          "unsigned",
+			authorization: "Bearer " + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, UserClaim{ID: "1"}),
+			wantErr:       ErrUnauthorized,
+			wantOptional:  ErrUnauthorized,
+		},
+		{
+			name:          "without id",
+			authorization: "Bearer " + sign(t, jwt.SigningMethodHS512, key, UserClaim{Email: "a@example.com"}),
+			wantErr:       ErrUnauthorized,
+			wantOptional:  ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			var claim *UserClaim
+			var err, optionalErr error
+			verifier.Handler(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				claim, err = UserFromRequest(r)
+				_, optionalErr = OptionalUserFromRequest(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if err != tt.wantErr || optionalErr != tt.wantOptional {
+				t.Fatalf("got errors %v and %v optionally, want %v and %v", err, optionalErr, tt.wantErr, tt.wantOptional)
+			}
+			if err == nil && claim.ID != tt.wantID {
+				t.Errorf("got user %q, want %q", claim.ID, tt.wantID)
+			}
+		})
+	}
+
+	// Requests which skipped the handler are never authenticated.
+	if _, err := OptionalUserFromRequest(httptest.NewRequest(http.MethodGet, "/", nil)); err != ErrUnauthorized {
+		t.Errorf("without the handler: got error %v, want %v", err, ErrUnauthorized)
+	}
+}
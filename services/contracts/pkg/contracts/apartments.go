@@ -0,0 +1,44 @@
+package contracts
+
+// Apartment is the part of an apartment other services rely on.
+type Apartment struct {
+	ID      string `json:"_id"`
+	Title   string `json:"title"`
+	Address string `json:"address"`
+	Owner   string `json:"owner"`
+	Status  string `json:"status"`
+	City    string `json:"city"`
+	// Price is the nightly price of the apartment.
+	Price float64 `json:"price"`
+	// TimeZone is the IANA time zone of the apartment city, reservation dates are days in it.
+	TimeZone string `json:"timeZone"`
+}
+
+type GetApartmentByIDRequest struct {
+	ApartmentID string `json:"apartmentId"`
+	// UserID is the user the apartment is requested for, owners can see their unpublished apartments.
+	UserID string `json:"userId,omitempty"`
+}
+
+type GetApartmentByIDResponse struct {
+	Apartment *Apartment  `json:"apartment"`
+	Err       *ReplyError `json:"error,omitempty"`
+}
+
+func (g GetApartmentByIDResponse) Error() error {
+	return replyError(g.Err)
+}
+
+type GetApartmentsByOwnerRequest struct {
+	OwnerID string `json:"ownerId"`
+}
+
+// GetApartmentsByOwnerResponse lists all apartments of the owner, whatever their status.
+type GetApartmentsByOwnerResponse struct {
+	Apartments []Apartment `json:"apartments"`
+	Err        *ReplyError `json:"error,omitempty"`
+}
+
+func (g GetApartmentsByOwnerResponse) Error() error {
+	return replyError(g.Err)
+}
@@ -0,0 +1,47 @@
+package contracts
+
+import "time"
+
+type HasFutureReservationsRequest struct {
+	ApartmentID string `json:"apartmentId"`
+}
+
+type HasFutureReservationsResponse struct {
+	HasFutureReservations bool        `json:"hasFutureReservations"`
+	Err                   *ReplyError `json:"error,omitempty"`
+}
+
+func (h HasFutureReservationsResponse) Error() error {
+	return replyError(h.Err)
+}
+
+// MaxBusyApartmentsWindow is the widest window GetBusyApartmentsRequest may ask about, wider ones are replied
+// with CodeInvalidArgument.
+const MaxBusyApartmentsWindow = 40 * 24 * time.Hour
+
+// GetBusyApartmentsRequest asks for the apartments with reservations overlapping the window.
+type GetBusyApartmentsRequest struct {
+	CheckIn  time.Time `json:"checkIn"`
+	CheckOut time.Time `json:"checkOut"`
+}
+
+type GetBusyApartmentsResponse struct {
+	ApartmentIDs []string    `json:"apartmentIds"`
+	Err          *ReplyError `json:"error,omitempty"`
+}
+
+func (g GetBusyApartmentsResponse) Error() error {
+	return replyError(g.Err)
+}
+
+// MessageCreatedEvent is published for every new message, so that the recipient can be notified.
+type MessageCreatedEvent struct {
+	MessageID     string    `json:"messageId"`
+	ThreadID      string    `json:"threadId"`
+	ApartmentID   string    `json:"apartmentId"`
+	ReservationID string    `json:"reservationId,omitempty"`
+	SenderID      string    `json:"senderId"`
+	RecipientID   string    `json:"recipientId"`
+	Body          string    `json:"body"`
+	Created       time.Time `json:"created"`
+}
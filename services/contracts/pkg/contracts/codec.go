@@ -0,0 +1,193 @@
+package contracts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeHeader names the encoding of a message. Messages without it are JSON, as sent by services
+// which predate the header.
+const ContentTypeHeader = "Content-Type"
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+var ErrUnknownMessage = errors.New("not a message of the contracts")
+
+type contentTypeKey struct{}
+
+// SupportedContentType tells if messages can be encoded with contentType.
+func SupportedContentType(contentType string) bool {
+	return contentType == ContentTypeJSON || contentType == ContentTypeProtobuf
+}
+
+// ContentTypeToContext is a subscriber RequestFunc keeping the content type of the request, so that
+// EncodeResponse replies in the same encoding.
+func ContentTypeToContext(ctx context.Context, msg *nats.Msg) context.Context {
+	return context.WithValue(ctx, contentTypeKey{}, contentType(msg))
+}
+
+func contentTypeFromContext(ctx context.Context) string {
+	if contentType, ok := ctx.Value(contentTypeKey{}).(string); ok {
+		return contentType
+	}
+	return ContentTypeJSON
+}
+
+func contentType(msg *nats.Msg) string {
+	if contentType := msg.Header.Get(ContentTypeHeader); contentType != "" {
+		return contentType
+	}
+	return ContentTypeJSON
+}
+
+// The encoders and decoders match the go-kit NATS transport function types, so that they can be passed
+// to publishers and subscribers as they are.
+
+// EncodeRequest writes the request as JSON into the message to publish.
+func EncodeRequest(ctx context.Context, msg *nats.Msg, request interface{}) error {
+	return NewRequestEncoder(ContentTypeJSON)(ctx, msg, request)
+}
+
+// NewRequestEncoder returns an encoder writing requests with contentType. Responses come back
+// in the same content type.
+func NewRequestEncoder(contentType string) func(context.Context, *nats.Msg, interface{}) error {
+	return func(_ context.Context, msg *nats.Msg, request interface{}) error {
+		data, err := marshal(contentType, request)
+		if err != nil {
+			return err
+		}
+		setContentType(msg, contentType)
+		msg.Data = data
+		return nil
+	}
+}
+
+// EncodeResponse publishes the response to the reply subject of the request, in the content type
+// of the request kept by ContentTypeToContext.
+func EncodeResponse(ctx context.Context, reply string, nc *nats.Conn, response interface{}) error {
+	contentType := contentTypeFromContext(ctx)
+	data, err := marshal(contentType, response)
+	if err != nil {
+		return err
+	}
+	msg := &nats.Msg{Subject: reply, Data: data}
+	setContentType(msg, contentType)
+	return nc.PublishMsg(msg)
+}
+
+// Marshal encodes a message of this package as JSON, for exchanges without a reply, like events.
+func Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func setContentType(msg *nats.Msg, contentType string) {
+	if msg.Header == nil {
+		msg.Header = make(nats.Header)
+	}
+	msg.Header.Set(ContentTypeHeader, contentType)
+}
+
+func marshal(contentType string, v interface{}) ([]byte, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		return json.Marshal(v)
+	case ContentTypeProtobuf:
+		m, err := toProto(v)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(m)
+	default:
+		return nil, ErrUnsupportedContentType
+	}
+}
+
+func unmarshal(msg *nats.Msg, v interface{}) error {
+	switch contentType(msg) {
+	case ContentTypeJSON:
+		return json.Unmarshal(msg.Data, v)
+	case ContentTypeProtobuf:
+		return unmarshalProto(msg.Data, v)
+	default:
+		return ErrUnsupportedContentType
+	}
+}
+
+func DecodeGetApartmentByIDRequest(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var request GetApartmentByIDRequest
+	if err := unmarshal(msg, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func DecodeGetApartmentByIDResponse(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var response GetApartmentByIDResponse
+	if err := unmarshal(msg, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func DecodeGetApartmentsByOwnerRequest(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var request GetApartmentsByOwnerRequest
+	if err := unmarshal(msg, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func DecodeGetApartmentsByOwnerResponse(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var response GetApartmentsByOwnerResponse
+	if err := unmarshal(msg, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func DecodeHasFutureReservationsRequest(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var request HasFutureReservationsRequest
+	if err := unmarshal(msg, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func DecodeHasFutureReservationsResponse(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var response HasFutureReservationsResponse
+	if err := unmarshal(msg, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func DecodeGetBusyApartmentsRequest(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var request GetBusyApartmentsRequest
+	if err := unmarshal(msg, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func DecodeGetBusyApartmentsResponse(_ context.Context, msg *nats.Msg) (interface{}, error) {
+	var response GetBusyApartmentsResponse
+	if err := unmarshal(msg, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// DecodeMessageCreatedEvent reads the event of a message received on SubjectMessageCreated.
+func DecodeMessageCreatedEvent(msg *nats.Msg) (MessageCreatedEvent, error) {
+	var event MessageCreatedEvent
+	err := json.Unmarshal(msg.Data, &event)
+	return event, err
+}
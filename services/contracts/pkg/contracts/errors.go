@@ -0,0 +1,31 @@
+package contracts
+
+// ErrorCode classifies the errors of NATS replies, so that the requesting service can react to them
+// without parsing messages.
+type ErrorCode string
+
+const (
+	CodeNotFound        ErrorCode = "not_found"
+	CodeInvalidArgument ErrorCode = "invalid_argument"
+	CodeUnavailable     ErrorCode = "unavailable"
+	CodeInternal        ErrorCode = "internal"
+)
+
+// ReplyError is the error envelope of NATS replies. Retryable errors may succeed when the request is repeated.
+type ReplyError struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Retryable bool      `json:"retryable"`
+}
+
+func (e *ReplyError) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// replyError keeps a nil envelope a nil error.
+func replyError(e *ReplyError) error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
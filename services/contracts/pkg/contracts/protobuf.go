@@ -0,0 +1,146 @@
+package contracts
+
+import (
+	"contracts/pkg/contractspb"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProto maps a message of this package to its protobuf counterpart.
+func toProto(v interface{}) (proto.Message, error) {
+	switch m := v.(type) {
+	case GetApartmentByIDRequest:
+		return &contractspb.GetApartmentByIDRequest{ApartmentId: m.ApartmentID, UserId: m.UserID}, nil
+	case GetApartmentByIDResponse:
+		return &contractspb.GetApartmentByIDResponse{Apartment: apartmentToProto(m.Apartment), Error: replyErrorToProto(m.Err)}, nil
+	case GetApartmentsByOwnerRequest:
+		return &contractspb.GetApartmentsByOwnerRequest{OwnerId: m.OwnerID}, nil
+	case GetApartmentsByOwnerResponse:
+		apartments := make([]*contractspb.Apartment, 0, len(m.Apartments))
+		for i := range m.Apartments {
+			apartments = append(apartments, apartmentToProto(&m.Apartments[i]))
+		}
+		return &contractspb.GetApartmentsByOwnerResponse{Apartments: apartments, Error: replyErrorToProto(m.Err)}, nil
+	case HasFutureReservationsRequest:
+		return &contractspb.HasFutureReservationsRequest{ApartmentId: m.ApartmentID}, nil
+	case HasFutureReservationsResponse:
+		return &contractspb.HasFutureReservationsResponse{
+			HasFutureReservations: m.HasFutureReservations,
+			Error:                 replyErrorToProto(m.Err),
+		}, nil
+	case GetBusyApartmentsRequest:
+		return &contractspb.GetBusyApartmentsRequest{CheckIn: timestamppb.New(m.CheckIn), CheckOut: timestamppb.New(m.CheckOut)}, nil
+	case GetBusyApartmentsResponse:
+		return &contractspb.GetBusyApartmentsResponse{ApartmentIds: m.ApartmentIDs, Error: replyErrorToProto(m.Err)}, nil
+	default:
+		return nil, ErrUnknownMessage
+	}
+}
+
+// unmarshalProto decodes data into v, a pointer to a message of this package.
+func unmarshalProto(data []byte, v interface{}) error {
+	switch m := v.(type) {
+	case *GetApartmentByIDRequest:
+		var p contractspb.GetApartmentByIDRequest
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = GetApartmentByIDRequest{ApartmentID: p.ApartmentId, UserID: p.UserId}
+	case *GetApartmentByIDResponse:
+		var p contractspb.GetApartmentByIDResponse
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = GetApartmentByIDResponse{Apartment: apartmentFromProto(p.Apartment), Err: replyErrorFromProto(p.Error)}
+	case *GetApartmentsByOwnerRequest:
+		var p contractspb.GetApartmentsByOwnerRequest
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = GetApartmentsByOwnerRequest{OwnerID: p.OwnerId}
+	case *GetApartmentsByOwnerResponse:
+		var p contractspb.GetApartmentsByOwnerResponse
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		apartments := make([]Apartment, 0, len(p.Apartments))
+		for _, a := range p.Apartments {
+			apartments = append(apartments, *apartmentFromProto(a))
+		}
+		*m = GetApartmentsByOwnerResponse{Apartments: apartments, Err: replyErrorFromProto(p.Error)}
+	case *HasFutureReservationsRequest:
+		var p contractspb.HasFutureReservationsRequest
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = HasFutureReservationsRequest{ApartmentID: p.ApartmentId}
+	case *HasFutureReservationsResponse:
+		var p contractspb.HasFutureReservationsResponse
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = HasFutureReservationsResponse{HasFutureReservations: p.HasFutureReservations, Err: replyErrorFromProto(p.Error)}
+	case *GetBusyApartmentsRequest:
+		var p contractspb.GetBusyApartmentsRequest
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = GetBusyApartmentsRequest{CheckIn: p.CheckIn.AsTime(), CheckOut: p.CheckOut.AsTime()}
+	case *GetBusyApartmentsResponse:
+		var p contractspb.GetBusyApartmentsResponse
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = GetBusyApartmentsResponse{ApartmentIDs: p.ApartmentIds, Err: replyErrorFromProto(p.Error)}
+	default:
+		return ErrUnknownMessage
+	}
+	return nil
+}
+
+func apartmentToProto(a *Apartment) *contractspb.Apartment {
+	if a == nil {
+		return nil
+	}
+	return &contractspb.Apartment{
+		Id:       a.ID,
+		Title:    a.Title,
+		Address:  a.Address,
+		Owner:    a.Owner,
+		Status:   a.Status,
+		City:     a.City,
+		Price:    a.Price,
+		TimeZone: a.TimeZone,
+	}
+}
+
+func apartmentFromProto(a *contractspb.Apartment) *Apartment {
+	if a == nil {
+		return nil
+	}
+	return &Apartment{
+		ID:       a.Id,
+		Title:    a.Title,
+		Address:  a.Address,
+		Owner:    a.Owner,
+		Status:   a.Status,
+		City:     a.City,
+		Price:    a.Price,
+		TimeZone: a.TimeZone,
+	}
+}
+
+func replyErrorToProto(e *ReplyError) *contractspb.ReplyError {
+	if e == nil {
+		return nil
+	}
+	return &contractspb.ReplyError{Code: string(e.Code), Message: e.Message, Retryable: e.Retryable}
+}
+
+func replyErrorFromProto(e *contractspb.ReplyError) *ReplyError {
+	if e == nil {
+		return nil
+	}
+	return &ReplyError{Code: ErrorCode(e.Code), Message: e.Message, Retryable: e.Retryable}
+}
@@ -0,0 +1,94 @@
+package contracts
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	kitnats "github.com/go-kit/kit/transport/nats"
+	"github.com/nats-io/nats.go"
+)
+
+const defaultPublisherTimeout = 10 * time.Second
+
+// Publisher wraps a NATS subject into a client endpoint like the go-kit NATS publisher, which drops the headers
+// of the message and with them its content type.
+type Publisher struct {
+	nc        *nats.Conn
+	subject   string
+	enc       kitnats.EncodeRequestFunc
+	dec       kitnats.DecodeResponseFunc
+	before    []kitnats.RequestFunc
+	after     []kitnats.PublisherResponseFunc
+	finalizer []PublisherFinalizerFunc
+	timeout   time.Duration
+}
+
+type PublisherOption func(*Publisher)
+
+// PublisherFinalizerFunc is called with the result of every request, the response is nil when err is not.
+type PublisherFinalizerFunc func(ctx context.Context, response interface{}, err error)
+
+func NewPublisher(
+	nc *nats.Conn,
+	subject string,
+	enc kitnats.EncodeRequestFunc,
+	dec kitnats.DecodeResponseFunc,
+	options ...PublisherOption,
+) *Publisher {
+	p := &Publisher{nc: nc, subject: subject, enc: enc, dec: dec, timeout: defaultPublisherTimeout}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// PublisherBefore sets the functions applied to the request message after it is encoded.
+func PublisherBefore(before ...kitnats.RequestFunc) PublisherOption {
+	return func(p *Publisher) { p.before = append(p.before, before...) }
+}
+
+// PublisherAfter sets the functions applied to the reply message before it is decoded.
+func PublisherAfter(after ...kitnats.PublisherResponseFunc) PublisherOption {
+	return func(p *Publisher) { p.after = append(p.after, after...) }
+}
+
+// PublisherFinalizer sets the functions called at the end of every request, whether it failed or not.
+func PublisherFinalizer(finalizer ...PublisherFinalizerFunc) PublisherOption {
+	return func(p *Publisher) { p.finalizer = append(p.finalizer, finalizer...) }
+}
+
+func PublisherTimeout(timeout time.Duration) PublisherOption {
+	return func(p *Publisher) { p.timeout = timeout }
+}
+
+func (p Publisher) Endpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		ctx, cancel := context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+		if len(p.finalizer) > 0 {
+			defer func() {
+				for _, f := range p.finalizer {
+					f(ctx, response, err)
+				}
+			}()
+		}
+
+		msg := &nats.Msg{Subject: p.subject}
+		if err = p.enc(ctx, msg, request); err != nil {
+			return nil, err
+		}
+		for _, f := range p.before {
+			ctx = f(ctx, msg)
+		}
+
+		reply, err := p.nc.RequestMsgWithContext(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range p.after {
+			ctx = f(ctx, reply)
+		}
+		return p.dec(ctx, reply)
+	}
+}
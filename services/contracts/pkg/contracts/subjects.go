@@ -0,0 +1,25 @@
+// Package contracts holds the messages services exchange over NATS: the subjects, the message types
+// and the helpers to encode and decode them. Both sides of an exchange use it, so that they can not drift apart.
+package contracts
+
+// Version is the version of the messages. A change that old services can not read gets a new version,
+// so that the old and the new subjects can be served side by side during a rollout.
+const Version = "v1"
+
+const (
+	// SubjectGetApartmentByID is requested with GetApartmentByIDRequest and replied with GetApartmentByIDResponse.
+	SubjectGetApartmentByID = "apartments." + Version + ".getApartmentById"
+	// SubjectGetApartmentsByOwner is requested with GetApartmentsByOwnerRequest and replied with
+	// GetApartmentsByOwnerResponse.
+	SubjectGetApartmentsByOwner = "apartments." + Version + ".getApartmentsByOwner"
+	// SubjectPingApartments is requested with an empty message and replied with an empty one while the
+	// apartments service serves requests, it touches no apartments.
+	SubjectPingApartments = "apartments." + Version + ".ping"
+	// SubjectHasFutureReservations is requested with HasFutureReservationsRequest and replied with
+	// HasFutureReservationsResponse.
+	SubjectHasFutureReservations = "booking." + Version + ".hasFutureReservations"
+	// SubjectGetBusyApartments is requested with GetBusyApartmentsRequest and replied with GetBusyApartmentsResponse.
+	SubjectGetBusyApartments = "booking." + Version + ".getBusyApartments"
+	// SubjectMessageCreated carries a MessageCreatedEvent for every new message, nobody replies.
+	SubjectMessageCreated = "messages." + Version + ".created"
+)
@@ -0,0 +1,3 @@
+package contractspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative contracts.proto
@@ -0,0 +1,219 @@
+package nats_tracing
+
+import (
+	"context"
+
+	"contracts/pkg/contracts"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	kitnats "github.com/go-kit/kit/transport/nats"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the OpenTelemetry scope of the spans of this package.
+const instrumentationName = "contracts/pkg/nats-tracing"
+
+// Span tags set on every span besides the ones of the Tags option.
+const (
+	TagSubject     = "nats.subject"
+	TagQueue       = "nats.queue"
+	TagPayloadSize = "nats.payload_size"
+)
+
+type TracerOption func(o *tracerOptions)
+
+type tracerOptions struct {
+	tags           map[string]string
+	name           string
+	logger         log.Logger
+	propagate      bool
+	compatEnvelope bool
+	propagator     propagation.TextMapPropagator
+}
+
+// Errorer is implemented by responses which carry the error of the request, like the replies of contracts.
+type Errorer interface {
+	Error() error
+}
+
+func newTracerOptions(options []TracerOption) tracerOptions {
+	config := tracerOptions{
+		tags:       make(map[string]string),
+		name:       "",
+		logger:     log.NewNopLogger(),
+		propagate:  true,
+		propagator: DefaultPropagator,
+	}
+
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// NATSSubscriberTrace starts a server span for every request, a child of the span context sent along with it.
+// Errors of requests are recorded by TraceEndpointErrors, subscribers do not see responses.
+func NATSSubscriberTrace(provider trace.TracerProvider, options ...TracerOption) kitnats.SubscriberOption {
+	config := newTracerOptions(options)
+	tracer := provider.Tracer(instrumentationName)
+
+	subscriberBefore := kitnats.SubscriberBefore(func(ctx context.Context, msg *nats.Msg) context.Context {
+		if config.propagate {
+			var err error
+			if ctx, err = extractNATS(ctx, msg, config.propagator, config.compatEnvelope); err != nil {
+				_ = config.logger.Log("err", err)
+			}
+		}
+
+		attributes := messageAttributes(config.tags, msg)
+		if msg.Sub != nil && msg.Sub.Queue != "" {
+			attributes = append(attributes, attribute.String(TagQueue, msg.Sub.Queue))
+		}
+		ctx, _ = tracer.Start(ctx, config.spanName(msg), trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+		return ctx
+	})
+
+	// The span only ends in the finalizer, which also runs for requests failing before the response is sent.
+	finalizer := kitnats.SubscriberFinalizer(func(ctx context.Context, msg *nats.Msg) {
+		trace.SpanFromContext(ctx).End()
+	})
+
+	return func(subscriber *kitnats.Subscriber) {
+		subscriberBefore(subscriber)
+		finalizer(subscriber)
+	}
+}
+
+// NATSPublisherTrace starts a client span for every request, a child of the span in the context, and sends
+// its context in the headers of the request. Failed requests and responses with errors are recorded as errors.
+func NATSPublisherTrace(provider trace.TracerProvider, options ...TracerOption) contracts.PublisherOption {
+	config := newTracerOptions(options)
+	tracer := provider.Tracer(instrumentationName)
+
+	publisherBefore := contracts.PublisherBefore(func(ctx context.Context, msg *nats.Msg) context.Context {
+		ctx, _ = tracer.Start(ctx, config.spanName(msg),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(messageAttributes(config.tags, msg)...),
+		)
+
+		if config.propagate {
+			if err := injectNATS(ctx, msg, config.propagator, config.compatEnvelope); err != nil {
+				_ = config.logger.Log("err", err)
+			}
+		}
+		return ctx
+	})
+
+	publisherFinalizer := contracts.PublisherFinalizer(func(ctx context.Context, response interface{}, err error) {
+		span := trace.SpanFromContext(ctx)
+		recordError(span, response, err)
+		span.End()
+	})
+
+	return func(publisher *contracts.Publisher) {
+		publisherBefore(publisher)
+		publisherFinalizer(publisher)
+	}
+}
+
+// TraceEndpointErrors records the error of the request on its span when the endpoint fails or its response is
+// an Errorer with an error. It wraps the endpoints of subscribers traced with NATSSubscriberTrace.
+func TraceEndpointErrors(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := next(ctx, request)
+		recordError(trace.SpanFromContext(ctx), response, err)
+		return response, err
+	}
+}
+
+func recordError(span trace.Span, response interface{}, err error) {
+	if err == nil {
+		if errorer, ok := response.(Errorer); ok {
+			err = errorer.Error()
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+func (o tracerOptions) spanName(msg *nats.Msg) string {
+	if o.name != "" {
+		return o.name
+	}
+	return msg.Subject
+}
+
+// messageAttributes are the tags and the attributes of the message. The payload size is the size of the data
+// without the trace envelope.
+func messageAttributes(tags map[string]string, msg *nats.Msg) []attribute.KeyValue {
+	attributes := make([]attribute.KeyValue, 0, len(tags)+3)
+	for key, value := range tags {
+		attributes = append(attributes, attribute.String(key, value))
+	}
+	return append(attributes, attribute.String(TagSubject, msg.Subject), attribute.Int(TagPayloadSize, len(msg.Data)))
+}
+
+// Options combines options into one, so that options shared by several endpoints can be passed along.
+func Options(options ...TracerOption) TracerOption {
+	return func(o *tracerOptions) {
+		for _, option := range options {
+			option(o)
+		}
+	}
+}
+
+func SetName(name string) TracerOption {
+	return func(o *tracerOptions) {
+		o.name = name
+	}
+}
+
+// Tags adds tags to every span.
+func Tags(tags map[string]string) TracerOption {
+	return func(o *tracerOptions) {
+		for key, value := range tags {
+			o.tags[key] = value
+		}
+	}
+}
+
+// Logger logs the errors of trace context propagation, they are dropped by default.
+func Logger(logger log.Logger) TracerOption {
+	return func(o *tracerOptions) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// AllowPropagation turns sending and receiving trace context on or off, it is on by default.
+func AllowPropagation(propagate bool) TracerOption {
+	return func(o *tracerOptions) {
+		o.propagate = propagate
+	}
+}
+
+// Propagator replaces DefaultPropagator, the propagator of trace context.
+func Propagator(propagator propagation.TextMapPropagator) TracerOption {
+	return func(o *tracerOptions) {
+		if propagator != nil {
+			o.propagator = propagator
+		}
+	}
+}
+
+// CompatEnvelope makes publishers also wrap messages into the JSON envelope of services predating trace headers,
+// and subscribers look for it in messages without headers. It is meant for rollouts only, plain messages
+// which happen to look like the envelope are unwrapped too.
+func CompatEnvelope(compat bool) TracerOption {
+	return func(o *tracerOptions) {
+		o.compatEnvelope = compat
+	}
+}
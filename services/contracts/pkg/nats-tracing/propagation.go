@@ -0,0 +1,151 @@
+package nats_tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrEmptyContext = errors.New("empty request context")
+
+// EnvelopeHeader marks messages whose data is wrapped into the JSON envelope, see CompatEnvelope.
+const EnvelopeHeader = "Trace-Envelope"
+
+// DefaultPropagator sends the trace context of requests in W3C traceparent and B3 headers, the W3C header
+// is read first.
+var DefaultPropagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)),
+)
+
+// envelopePropagator reads and writes the B3 map of the envelope.
+var envelopePropagator = b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader))
+
+// natsMessageWithContext is the envelope trace context was sent in before headers. B3 is the shape of natszipkin,
+// Sc the shape of earlier versions of this package.
+type natsMessageWithContext struct {
+	B3   map[string]string `json:"natsSpanContextB3Map,omitempty"`
+	Sc   *envelopeContext  `json:"sc,omitempty"`
+	Data []byte            `json:"data"`
+}
+
+// envelopeContext is a Zipkin span context as encoded in JSON, 64 bit trace ids are shorter.
+type envelopeContext struct {
+	TraceID string `json:"traceId"`
+	ID      string `json:"id"`
+}
+
+// headerCarrier carries trace context in NATS headers. Keys are looked up regardless of their case, as
+// publishers did not agree on it.
+type headerCarrier nats.Header
+
+func (c headerCarrier) Get(key string) string {
+	if values := c[key]; len(values) > 0 {
+		return values[0]
+	}
+	for k, values := range c {
+		if len(values) > 0 && strings.EqualFold(k, key) {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = []string{value}
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// extractNATS returns ctx with the span context sent in the headers of a NATS message. The data of messages
+// marked with EnvelopeHeader is unwrapped, with compatEnvelope so is the data of messages without headers
+// when it has the shape of the envelope. Headers take precedence over the envelope.
+func extractNATS(ctx context.Context, msg *nats.Msg, propagator propagation.TextMapPropagator, compatEnvelope bool) (context.Context, error) { //nolint:lll
+	var enveloped context.Context
+	if msg.Header.Get(EnvelopeHeader) != "" || (compatEnvelope && len(msg.Header) == 0) {
+		enveloped = unwrapEnvelope(ctx, msg)
+	}
+
+	if len(msg.Header) > 0 {
+		if extracted := propagator.Extract(ctx, headerCarrier(msg.Header)); trace.SpanContextFromContext(extracted).IsValid() {
+			return extracted, nil
+		}
+	}
+	if enveloped == nil {
+		return ctx, nil
+	}
+	if !trace.SpanContextFromContext(enveloped).IsValid() {
+		return ctx, ErrEmptyContext
+	}
+	return enveloped, nil
+}
+
+// injectNATS sends the span context of ctx in the headers of a NATS message, if it has a valid one. With
+// compatEnvelope the data is also wrapped into the envelope for subscribers predating headers.
+func injectNATS(ctx context.Context, msg *nats.Msg, propagator propagation.TextMapPropagator, compatEnvelope bool) error {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	if msg.Header == nil {
+		msg.Header = make(nats.Header)
+	}
+	propagator.Inject(ctx, headerCarrier(msg.Header))
+
+	if !compatEnvelope {
+		return nil
+	}
+	envelope := natsMessageWithContext{B3: make(map[string]string), Data: msg.Data}
+	envelopePropagator.Inject(ctx, propagation.MapCarrier(envelope.B3))
+	data, err := json.Marshal(&envelope)
+	if err != nil {
+		return err
+	}
+	msg.Data = data
+	msg.Header.Set(EnvelopeHeader, "b3")
+	return nil
+}
+
+// unwrapEnvelope replaces the data of the message with the enveloped one and returns ctx with the span context
+// of the envelope, which is not valid when the envelope carries none. Messages which are not an envelope are
+// left as they are and nil is returned.
+func unwrapEnvelope(ctx context.Context, msg *nats.Msg) context.Context {
+	var payload natsMessageWithContext
+	if err := json.Unmarshal(msg.Data, &payload); err != nil || (len(payload.B3) == 0 && payload.Sc == nil) {
+		return nil
+	}
+
+	msg.Data = payload.Data
+	if payload.Sc == nil {
+		carrier := make(propagation.MapCarrier, len(payload.B3))
+		for key, value := range payload.B3 {
+			carrier[strings.ToLower(key)] = value
+		}
+		return envelopePropagator.Extract(ctx, carrier)
+	}
+	traceID, err := trace.TraceIDFromHex(strings.Repeat("0", 32-min(len(payload.Sc.TraceID), 32)) + payload.Sc.TraceID)
+	if err != nil {
+		return ctx
+	}
+	spanID, err := trace.SpanIDFromHex(payload.Sc.ID)
+	if err != nil {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
@@ -0,0 +1,213 @@
+package contracttests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"apartments/pkg/apartments"
+	"booking/pkg/booking"
+	"contracts/pkg/contracts"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// apartmentsService serves the requests of the booking service, the other methods are not part of the contract.
+type apartmentsService struct {
+	apartments.Service
+	apartments []apartments.Apartment
+	err        error
+}
+
+func (s *apartmentsService) GetApartmentByID(_ context.Context, viewerID, apartmentID string) (*apartments.Apartment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for i := range s.apartments {
+		a := &s.apartments[i]
+		if a.ID.Hex() == apartmentID && (a.Status == apartments.StatusPublished || a.Owner == viewerID) {
+			return a, nil
+		}
+	}
+	return nil, apartments.ErrApartmentNotFound
+}
+
+func (s *apartmentsService) GetOwnerApartments(_ context.Context, ownerID string) ([]apartments.Apartment, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	owned := make([]apartments.Apartment, 0)
+	for _, a := range s.apartments {
+		if a.Owner == ownerID {
+			owned = append(owned, a)
+		}
+	}
+	return owned, nil
+}
+
+func newApartmentsClient(t testing.TB, s *apartmentsService, contentType string) *booking.ApartmentsRepositoryNATS {
+	nc := connect(t)
+	tracer := noop.NewTracerProvider()
+	apartments.MakeNatsHandler(s, nc, tracer, apartments.EndpointMetrics{})
+	return booking.NewApartmentsRepository(nc, tracer, contentType)
+}
+
+func testApartment(owner string, status apartments.ListingStatus) apartments.Apartment {
+	return apartments.Apartment{
+		ID:       primitive.NewObjectID(),
+		Title:    "Flat by the river",
+		Address:  "1 River street",
+		Owner:    owner,
+		Status:   status,
+		City:     "Lisbon",
+		TimeZone: "Europe/Lisbon",
+		Price:    80,
+	}
+}
+
+func TestGetApartmentByID(t *testing.T) {
+	for _, contentType := range contentTypes {
+		t.Run(contentType, func(t *testing.T) {
+			testGetApartmentByID(t, contentType)
+		})
+	}
+}
+
+func testGetApartmentByID(t *testing.T, contentType string) {
+	published := testApartment("owner", apartments.StatusPublished)
+	draft := testApartment("owner", apartments.StatusDraft)
+	client := newApartmentsClient(t, &apartmentsService{apartments: []apartments.Apartment{published, draft}}, contentType)
+	ctx := context.Background()
+
+	got, err := client.GetApartmentByID(ctx, "guest", published.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := booking.Apartment{
+		ID:       published.ID.Hex(),
+		Title:    published.Title,
+		Address:  published.Address,
+		Owner:    published.Owner,
+		Status:   string(published.Status),
+		City:     published.City,
+		Price:    published.Price,
+		TimeZone: published.TimeZone,
+	}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+
+	if _, err = client.GetApartmentByID(ctx, "guest", draft.ID.Hex()); err != booking.ErrNoApartmentWithGivenID {
+		t.Errorf("draft for a guest: got error %v, want %v", err, booking.ErrNoApartmentWithGivenID)
+	}
+	if _, err = client.GetApartmentByID(ctx, "owner", draft.ID.Hex()); err != nil {
+		t.Errorf("draft for its owner: got error %v", err)
+	}
+}
+
+func TestGetApartmentByIDErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "wrong id", err: apartments.ErrWrongIDFormat, want: booking.ErrWrongIDFormat},
+		{name: "database", err: apartments.ErrDatabase, want: booking.ErrApartmentsUnavailable},
+	}
+	for _, contentType := range contentTypes {
+		for _, tt := range tests {
+			t.Run(contentType+"/"+tt.name, func(t *testing.T) {
+				client := newApartmentsClient(t, &apartmentsService{err: tt.err}, contentType)
+				_, err := client.GetApartmentByID(context.Background(), "guest", "id")
+				if err != tt.want {
+					t.Errorf("got error %v, want %v", err, tt.want)
+				}
+			})
+		}
+	}
+
+	client := newApartmentsClient(t, &apartmentsService{err: errors.New("boom")}, contracts.ContentTypeJSON)
+	if _, err := client.GetApartmentByID(context.Background(), "guest", "id"); err == nil {
+		t.Error("internal error: got no error")
+	}
+}
+
+// TestLegacyGetApartmentByID requests the apartment the way services which predate the contracts do: JSON without
+// a content type on the unversioned subject, reading a reply whose error field is err.
+func TestLegacyGetApartmentByID(t *testing.T) {
+	published := testApartment("owner", apartments.StatusPublished)
+	draft := testApartment("owner", apartments.StatusDraft)
+	tests := []struct {
+		name        string
+		s           *apartmentsService
+		apartmentID string
+		wantID      string
+		wantErr     bool
+	}{
+		{name: "published", s: &apartmentsService{apartments: []apartments.Apartment{published}}, apartmentID: published.ID.Hex(),
+			wantID: published.ID.Hex()},
+		{name: "draft", s: &apartmentsService{apartments: []apartments.Apartment{draft}}, apartmentID: draft.ID.Hex()},
+		{name: "database", s: &apartmentsService{err: apartments.ErrDatabase}, apartmentID: published.ID.Hex(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nc := connect(t)
+			apartments.MakeNatsHandler(tt.s, nc, noop.NewTracerProvider(), apartments.EndpointMetrics{})
+			msg, err := nc.Request("apartments.getApartmentById", []byte(`{"apartmentId":"`+tt.apartmentID+`"}`), time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var reply struct {
+				Apartment struct {
+					ID string `json:"_id"`
+				} `json:"apartment"`
+				Err string `json:"err"`
+			}
+			if err = json.Unmarshal(msg.Data, &reply); err != nil {
+				t.Fatal(err)
+			}
+			if reply.Apartment.ID != tt.wantID || (reply.Err != "") != tt.wantErr {
+				t.Errorf("got apartment %q and error %q, want apartment %q", reply.Apartment.ID, reply.Err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestGetApartmentsByOwner(t *testing.T) {
+	for _, contentType := range contentTypes {
+		t.Run(contentType, func(t *testing.T) {
+			testGetApartmentsByOwner(t, contentType)
+		})
+	}
+}
+
+func testGetApartmentsByOwner(t *testing.T, contentType string) {
+	owned := []apartments.Apartment{
+		testApartment("owner", apartments.StatusPublished),
+		testApartment("owner", apartments.StatusDraft),
+	}
+	client := newApartmentsClient(t, &apartmentsService{
+		apartments: append(owned, testApartment("other", apartments.StatusPublished)),
+	}, contentType)
+
+	got, err := client.GetApartmentsByOwner(context.Background(), "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(owned) {
+		t.Fatalf("got %d apartments, want %d", len(got), len(owned))
+	}
+	for i := range owned {
+		if got[i].ID != owned[i].ID.Hex() || got[i].Status != string(owned[i].Status) {
+			t.Errorf("apartment %d: got %+v, want %+v", i, got[i], owned[i])
+		}
+	}
+
+	none, err := client.GetApartmentsByOwner(context.Background(), "nobody")
+	if err != nil || len(none) != 0 {
+		t.Errorf("got %v and error %v, want no apartments", none, err)
+	}
+}
@@ -0,0 +1,63 @@
+package contracttests
+
+import (
+	"context"
+	"testing"
+
+	"apartments/pkg/apartments"
+	"contracts/pkg/contracts"
+
+	"github.com/nats-io/nats.go"
+)
+
+// BenchmarkGetApartmentByID measures the round trip of the call from the booking client to the apartments
+// handler, with the size of the encoded request and reply.
+func BenchmarkGetApartmentByID(b *testing.B) {
+	apartment := testApartment("owner", apartments.StatusPublished)
+	for _, contentType := range contentTypes {
+		b.Run(contentType, func(b *testing.B) {
+			client := newApartmentsClient(b, &apartmentsService{apartments: []apartments.Apartment{apartment}}, contentType)
+			requestSize, replySize := wireSizes(b, apartment, contentType)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.GetApartmentByID(ctx, "guest", apartment.ID.Hex()); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(requestSize), "request-B")
+			b.ReportMetric(float64(replySize), "reply-B")
+		})
+	}
+}
+
+// wireSizes encodes the request and the reply of a getApartmentById call like the client and the handler do.
+func wireSizes(b *testing.B, apartment apartments.Apartment, contentType string) (int, int) {
+	ctx := context.Background()
+	request := &nats.Msg{}
+	err := contracts.NewRequestEncoder(contentType)(ctx, request, contracts.GetApartmentByIDRequest{
+		ApartmentID: apartment.ID.Hex(),
+		UserID:      "guest",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	reply := &nats.Msg{}
+	err = contracts.NewRequestEncoder(contentType)(ctx, reply, contracts.GetApartmentByIDResponse{
+		Apartment: &contracts.Apartment{
+			ID:       apartment.ID.Hex(),
+			Title:    apartment.Title,
+			Address:  apartment.Address,
+			Owner:    apartment.Owner,
+			Status:   string(apartment.Status),
+			City:     apartment.City,
+			Price:    apartment.Price,
+			TimeZone: apartment.TimeZone,
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return len(request.Data), len(reply.Data)
+}
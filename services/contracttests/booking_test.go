@@ -0,0 +1,142 @@
+package contracttests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"apartments/pkg/apartments"
+	"booking/pkg/booking"
+	"contracts/pkg/contracts"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// bookingService serves the requests of the apartments service, the other methods are not part of the contract.
+type bookingService struct {
+	booking.Service
+	reserved map[string]bool
+	busy     []string
+	err      error
+
+	checkIn, checkOut time.Time
+}
+
+func (s *bookingService) HasFutureReservations(_ context.Context, apartmentID string) (bool, error) {
+	return s.reserved[apartmentID], s.err
+}
+
+func (s *bookingService) GetBusyApartmentIDs(_ context.Context, start, end time.Time) ([]string, error) {
+	s.checkIn, s.checkOut = start, end
+	return s.busy, s.err
+}
+
+func newBookingClient(t *testing.T, s *bookingService, contentType string) *apartments.BookingRepositoryNATS {
+	nc := connect(t)
+	tracer := noop.NewTracerProvider()
+	booking.MakeNatsHandler(s, nc, tracer, booking.EndpointMetrics{})
+	return apartments.NewBookingRepository(nc, tracer, contentType)
+}
+
+func TestHasFutureReservations(t *testing.T) {
+	for _, contentType := range contentTypes {
+		client := newBookingClient(t, &bookingService{reserved: map[string]bool{"reserved": true}}, contentType)
+		for apartmentID, want := range map[string]bool{"reserved": true, "free": false} {
+			got, err := client.HasFutureReservations(context.Background(), apartmentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s, %s: got %v, want %v", contentType, apartmentID, got, want)
+			}
+		}
+	}
+}
+
+func TestHasFutureReservationsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      contracts.ErrorCode
+		retryable bool
+	}{
+		{name: "wrong id", err: booking.ErrWrongIDFormat, code: contracts.CodeInvalidArgument},
+		{name: "database", err: booking.ErrRequestingDatabase, code: contracts.CodeUnavailable, retryable: true},
+		{name: "internal", err: errors.New("boom"), code: contracts.CodeInternal},
+	}
+	for _, contentType := range contentTypes {
+		for _, tt := range tests {
+			t.Run(contentType+"/"+tt.name, func(t *testing.T) {
+				client := newBookingClient(t, &bookingService{err: tt.err}, contentType)
+				_, err := client.HasFutureReservations(context.Background(), "id")
+				var replyErr *contracts.ReplyError
+				if !errors.As(err, &replyErr) {
+					t.Fatalf("got error %v, want a reply error", err)
+				}
+				if replyErr.Code != tt.code || replyErr.Retryable != tt.retryable {
+					t.Errorf("got %+v, want code %s and retryable %v", replyErr, tt.code, tt.retryable)
+				}
+			})
+		}
+	}
+}
+
+func TestGetBusyApartmentIDs(t *testing.T) {
+	checkIn := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	checkOut := checkIn.AddDate(0, 0, 3)
+	for _, contentType := range contentTypes {
+		s := &bookingService{busy: []string{"first", "second"}}
+		client := newBookingClient(t, s, contentType)
+
+		got, err := client.GetBusyApartmentIDs(context.Background(), checkIn, checkOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, s.busy) {
+			t.Errorf("%s: got %v, want %v", contentType, got, s.busy)
+		}
+		if !s.checkIn.Equal(checkIn) || !s.checkOut.Equal(checkOut) {
+			t.Errorf("%s: booking got window %v - %v, want %v - %v", contentType, s.checkIn, s.checkOut, checkIn, checkOut)
+		}
+	}
+}
+
+func TestMessageCreatedEvent(t *testing.T) {
+	nc := connect(t)
+	received := make(chan *nats.Msg, 1)
+	if _, err := nc.ChanSubscribe(contracts.SubjectMessageCreated, received); err != nil {
+		t.Fatal(err)
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	event := booking.MessageCreatedEvent{
+		MessageID:   "message",
+		ThreadID:    "thread",
+		ApartmentID: "apartment",
+		SenderID:    "guest",
+		RecipientID: "owner",
+		Body:        "Is the flat available in July?",
+		Created:     time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := booking.NewEventPublisher(nc).PublishMessageCreated(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		got, err := contracts.DecodeMessageCreatedEvent(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, event) {
+			t.Errorf("got %+v, want %+v", got, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
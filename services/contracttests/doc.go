@@ -0,0 +1,3 @@
+// Package contracttests runs the NATS handlers of each service against the clients of the other service
+// over an embedded NATS server, so that a change of a message that breaks the other side fails the build.
+package contracttests
@@ -0,0 +1,58 @@
+package contracttests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"apartments/pkg/apartments"
+	"booking/pkg/booking"
+	"contracts/pkg/contracts"
+	"telemetry/pkg/health"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TestReadiness checks that booking is ready only while the apartments service answers its pings, and not
+// once it shuts down.
+func TestReadiness(t *testing.T) {
+	nc := connect(t)
+	tracer := noop.NewTracerProvider()
+	checks := health.New(time.Second)
+	checks.Add("nats", health.NATS(nc))
+	checks.Add("apartments", booking.NewApartmentsRepository(nc, tracer, contracts.ContentTypeJSON).Ping)
+
+	status, report := ready(t, checks)
+	if status != http.StatusServiceUnavailable || report.Status != health.StatusNotReady {
+		t.Errorf("without apartments service got %d %q, want 503 %q", status, report.Status, health.StatusNotReady)
+	}
+	if got := report.Dependencies["apartments"]; got.Status != health.StatusDown || got.Error == "" {
+		t.Errorf("apartments dependency %+v, want down with an error", got)
+	}
+	if got := report.Dependencies["nats"]; got.Status != health.StatusUp {
+		t.Errorf("nats dependency %+v, want up", got)
+	}
+
+	apartments.MakeNatsHandler(&apartmentsService{}, nc, tracer, apartments.EndpointMetrics{})
+	if status, report = ready(t, checks); status != http.StatusOK || report.Status != health.StatusReady {
+		t.Errorf("got %d %+v, want 200 %q", status, report, health.StatusReady)
+	}
+
+	checks.ShutDown()
+	if status, report = ready(t, checks); status != http.StatusServiceUnavailable || report.Status != health.StatusShuttingDown {
+		t.Errorf("shutting down got %d %q, want 503 %q", status, report.Status, health.StatusShuttingDown)
+	}
+}
+
+func ready(t *testing.T, checks *health.Health) (int, health.Report) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	checks.ReadyHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report health.Report
+	if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, report
+}
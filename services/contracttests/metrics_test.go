@@ -0,0 +1,109 @@
+package contracttests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"apartments/pkg/apartments"
+	"booking/pkg/booking"
+	"contracts/pkg/contracts"
+
+	"github.com/go-kit/kit/metrics"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TestEndpointMetrics checks that requests over NATS are counted by the class of their error on both sides.
+func TestEndpointMetrics(t *testing.T) {
+	nc := connect(t)
+	tracer := noop.NewTracerProvider()
+	apartment := testApartment("owner", apartments.StatusPublished)
+	service := &apartmentsService{apartments: []apartments.Apartment{apartment}}
+	requests, duration := newRecorded(), newRecorded()
+	apartments.MakeNatsHandler(service, nc, tracer, apartments.EndpointMetrics{Requests: requests, Duration: histogram{duration}})
+	lookupsFailed := newRecorded()
+	client := booking.NewInstrumentingApartmentsRepository(
+		lookupsFailed,
+		booking.NewApartmentsRepository(nc, tracer, contracts.ContentTypeJSON),
+	)
+
+	if _, err := client.GetApartmentByID(context.Background(), "guest", apartment.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	service.err = apartments.ErrDatabase
+	if _, err := client.GetApartmentByID(context.Background(), "guest", apartment.ID.Hex()); err == nil {
+		t.Fatal("got no error")
+	}
+
+	for _, labels := range []string{
+		"method=GetApartmentByID,transport=nats,error=none",
+		"method=GetApartmentByID,transport=nats,error=unavailable",
+	} {
+		if got := requests.value(labels); got != 1 {
+			t.Errorf("%s: %v requests, want 1", labels, got)
+		}
+		if got := len(duration.observed[labels]); got != 1 {
+			t.Errorf("%s: %d observed durations, want 1", labels, got)
+		}
+	}
+	if got := lookupsFailed.value("method=GetApartmentByID,error=unavailable"); got != 1 {
+		t.Errorf("%v failed lookups, want 1", got)
+	}
+}
+
+// recorded is a counter keeping what is recorded by label values, written as name=value pairs joined by
+// commas. The endpoints record requests before they are replied to.
+type recorded struct {
+	labels []string
+
+	mu       *sync.Mutex
+	values   map[string]float64
+	observed map[string][]float64
+}
+
+func newRecorded() *recorded {
+	return &recorded{mu: &sync.Mutex{}, values: make(map[string]float64), observed: make(map[string][]float64)}
+}
+
+func (r *recorded) with(labelValues []string) *recorded {
+	labels := append(append([]string{}, r.labels...), labelValues...)
+	return &recorded{labels: labels, mu: r.mu, values: r.values, observed: r.observed}
+}
+
+func (r *recorded) key() string {
+	pairs := make([]string, 0, len(r.labels)/2)
+	for i := 0; i+1 < len(r.labels); i += 2 {
+		pairs = append(pairs, r.labels[i]+"="+r.labels[i+1])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (r *recorded) With(labelValues ...string) metrics.Counter { return r.with(labelValues) }
+
+func (r *recorded) Add(delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[r.key()] += delta
+}
+
+func (r *recorded) Observe(value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observed[r.key()] = append(r.observed[r.key()], value)
+}
+
+func (r *recorded) value(labels string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[labels]
+}
+
+// histogram is a recorded histogram.
+type histogram struct {
+	*recorded
+}
+
+func (h histogram) With(labelValues ...string) metrics.Histogram {
+	return histogram{h.with(labelValues)}
+}
@@ -0,0 +1,94 @@
+package contracttests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"contracts/pkg/contracts"
+	"telemetry/pkg/telemetry"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contentTypes are the encodings every exchange is checked with.
+var contentTypes = []string{contracts.ContentTypeJSON, contracts.ContentTypeProtobuf}
+
+// connect starts an embedded NATS server on a random port and connects to it. Both are closed with the test.
+func connect(t testing.TB) *nats.Conn {
+	t.Helper()
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server is not ready")
+	}
+	t.Cleanup(ns.Shutdown)
+
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+// recordSpans sets up the telemetry of service with an in memory exporter, which every span is exported to as
+// soon as it ends.
+func recordSpans(t testing.TB, service string, exporter *tracetest.InMemoryExporter) trace.TracerProvider {
+	t.Helper()
+	tel, err := telemetry.Setup(context.Background(), telemetry.Config{
+		ServiceName:  service,
+		SpanExporter: exporter,
+		Metrics:      telemetry.ExporterNone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tel.TracerProvider
+}
+
+// waitForSpans waits until n spans are exported, subscribers end theirs after replying.
+func waitForSpans(t *testing.T, exporter *tracetest.InMemoryExporter, n int) tracetest.SpanStubs {
+	t.Helper()
+	var spans tracetest.SpanStubs
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if spans = exporter.GetSpans(); len(spans) >= n {
+			return spans
+		}
+	}
+	t.Fatalf("got %d spans, want %d", len(spans), n)
+	return nil
+}
+
+func spanNamed(spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return tracetest.SpanStub{}
+}
+
+func spanOfKind(spans tracetest.SpanStubs, kind trace.SpanKind) tracetest.SpanStub {
+	for _, span := range spans {
+		if span.SpanKind == kind {
+			return span
+		}
+	}
+	return tracetest.SpanStub{}
+}
+
+func attributeOf(span tracetest.SpanStub, key string) string {
+	for _, attribute := range span.Attributes {
+		if string(attribute.Key) == key {
+			return attribute.Value.Emit()
+		}
+	}
+	return ""
+}
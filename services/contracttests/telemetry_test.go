@@ -0,0 +1,59 @@
+package contracttests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"apartments/pkg/apartments"
+	"booking/pkg/booking"
+	"contracts/pkg/contracts"
+	"telemetry/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestSpansOfEachService checks that the spans of a request from booking to apartments are exported by the
+// telemetry of the service which made them, in the trace of the request.
+func TestSpansOfEachService(t *testing.T) {
+	nc := connect(t)
+	spans := tracetest.NewInMemoryExporter()
+	apartment := testApartment("owner", apartments.StatusPublished)
+	apartments.MakeNatsHandler(&apartmentsService{apartments: []apartments.Apartment{apartment}}, nc,
+		recordSpans(t, "apartments", spans), apartments.EndpointMetrics{})
+	bookingTracing := recordSpans(t, "booking", spans)
+	client := booking.NewApartmentsRepository(nc, bookingTracing, contracts.ContentTypeJSON)
+
+	ctx, parent := bookingTracing.Tracer("contracttests").Start(context.Background(), "book an apartment")
+	if _, err := client.GetApartmentByID(ctx, "guest", apartment.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	recorded := waitForSpans(t, spans, 3)
+	for kind, service := range map[trace.SpanKind]string{
+		trace.SpanKindInternal: "booking",
+		trace.SpanKindClient:   "booking",
+		trace.SpanKindServer:   "apartments",
+	} {
+		span := spanOfKind(recorded, kind)
+		if span.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+			t.Errorf("%s span is in trace %s, want %s", kind, span.SpanContext.TraceID(), parent.SpanContext().TraceID())
+		}
+		if got, _ := span.Resource.Set().Value("service.name"); got.AsString() != service {
+			t.Errorf("%s span is of service %q, want %q", kind, got.AsString(), service)
+		}
+	}
+}
+
+func TestUnknownExporters(t *testing.T) {
+	for _, config := range []telemetry.Config{
+		{ServiceName: "booking", Traces: "jaeger", Metrics: telemetry.ExporterNone},
+		{ServiceName: "booking", Traces: telemetry.ExporterNone, Metrics: "statsd"},
+	} {
+		if _, err := telemetry.Setup(context.Background(), config); !errors.Is(err, telemetry.ErrUnknownExporter) {
+			t.Errorf("traces %q and metrics %q: got error %v, want %v", config.Traces, config.Metrics, err, telemetry.ErrUnknownExporter)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package contracttests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apartments/pkg/apartments"
+	"booking/pkg/booking"
+	"contracts/pkg/auth"
+	"contracts/pkg/contracts"
+	nats_tracing "contracts/pkg/nats-tracing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-kit/kit/log"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	for _, compat := range []bool{false, true} {
+		nc := connect(t)
+		spans := tracetest.NewInMemoryExporter()
+		provider := recordSpans(t, "contracttests", spans)
+		apartment := testApartment("owner", apartments.StatusPublished)
+		service := &apartmentsService{apartments: []apartments.Apartment{apartment}}
+		apartments.MakeNatsHandler(service, nc, provider, apartments.EndpointMetrics{}, nats_tracing.CompatEnvelope(compat))
+		client := booking.NewApartmentsRepository(nc, provider, contracts.ContentTypeProtobuf, nats_tracing.CompatEnvelope(compat))
+
+		ctx, parent := provider.Tracer("contracttests").Start(context.Background(), "book an apartment")
+		_, err := client.GetApartmentByID(ctx, "guest", apartment.ID.Hex())
+		if err != nil {
+			t.Fatalf("compat %v: %v", compat, err)
+		}
+		parent.End()
+
+		recorded := waitForSpans(t, spans, 3)
+		clientSpan, serverSpan := spanOfKind(recorded, trace.SpanKindClient), spanOfKind(recorded, trace.SpanKindServer)
+		if clientSpan.SpanContext.TraceID() != parent.SpanContext().TraceID() || clientSpan.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("compat %v: client span %s is not a child of the request span", compat, clientSpan.SpanContext.SpanID())
+		}
+		if serverSpan.SpanContext.TraceID() != clientSpan.SpanContext.TraceID() || serverSpan.Parent.SpanID() != clientSpan.SpanContext.SpanID() {
+			t.Errorf("compat %v: server span %s is not a child of the client span %s",
+				compat, serverSpan.SpanContext.SpanID(), clientSpan.SpanContext.SpanID())
+		}
+		if !serverSpan.Parent.IsRemote() {
+			t.Errorf("compat %v: server span parent is not remote", compat)
+		}
+		subject, queue := attributeOf(serverSpan, nats_tracing.TagSubject), attributeOf(serverSpan, nats_tracing.TagQueue)
+		if subject != contracts.SubjectGetApartmentByID || queue == "" {
+			t.Errorf("compat %v: server span attributes %v, want the subject and the queue", compat, serverSpan.Attributes)
+		}
+	}
+}
+
+// TestTraceHeaders checks that requests carry their trace context both in W3C and in B3 headers.
+func TestTraceHeaders(t *testing.T) {
+	nc := connect(t)
+	headers := make(chan nats.Header, 1)
+	_, err := nc.Subscribe(contracts.SubjectGetApartmentByID, func(msg *nats.Msg) {
+		headers <- msg.Header
+		_ = msg.Respond([]byte("{}"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := recordSpans(t, "contracttests", tracetest.NewInMemoryExporter())
+	client := booking.NewApartmentsRepository(nc, provider, contracts.ContentTypeJSON)
+
+	ctx, parent := provider.Tracer("contracttests").Start(context.Background(), "book an apartment")
+	_, _ = client.GetApartmentByID(ctx, "guest", "id")
+	parent.End()
+
+	header := <-headers
+	traceID := parent.SpanContext().TraceID().String()
+	if got := header.Get("traceparent"); !strings.HasPrefix(got, "00-"+traceID+"-") {
+		t.Errorf("got traceparent %q, want one of trace %s", got, traceID)
+	}
+	if got := header.Get("x-b3-traceid"); got != traceID {
+		t.Errorf("got B3 trace id %q, want %s", got, traceID)
+	}
+}
+
+// TestTraceContextOfOtherPublishers sends requests with the trace context in only one of the header formats.
+func TestTraceContextOfOtherPublishers(t *testing.T) {
+	const (
+		traceID = "0af7651916cd43dd8448eb211c80319c"
+		spanID  = "b7ad6b7169203331"
+	)
+	tests := []struct {
+		name   string
+		header nats.Header
+	}{
+		{name: "w3c", header: nats.Header{"traceparent": {"00-" + traceID + "-" + spanID + "-01"}}},
+		{name: "b3", header: nats.Header{"X-B3-TraceId": {traceID}, "X-B3-SpanId": {spanID}, "X-B3-Sampled": {"1"}}},
+		{name: "b3 single", header: nats.Header{"b3": {traceID + "-" + spanID + "-1"}}},
+	}
+	for _, tt := range tests {
+		nc := connect(t)
+		spans := tracetest.NewInMemoryExporter()
+		apartment := testApartment("owner", apartments.StatusPublished)
+		service := &apartmentsService{apartments: []apartments.Apartment{apartment}}
+		apartments.MakeNatsHandler(service, nc, recordSpans(t, "apartments", spans), apartments.EndpointMetrics{})
+
+		data, err := json.Marshal(contracts.GetApartmentByIDRequest{ApartmentID: apartment.ID.Hex()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &nats.Msg{Subject: contracts.SubjectGetApartmentByID, Header: tt.header, Data: data}
+		if _, err = nc.RequestMsg(msg, 5*time.Second); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		serverSpan := spanOfKind(waitForSpans(t, spans, 1), trace.SpanKindServer)
+		if serverSpan.SpanContext.TraceID().String() != traceID || serverSpan.Parent.SpanID().String() != spanID {
+			t.Errorf("%s: got span of trace %s with parent %s", tt.name, serverSpan.SpanContext.TraceID(), serverSpan.Parent.SpanID())
+		}
+	}
+}
+
+func TestTraceErrors(t *testing.T) {
+	nc := connect(t)
+	spans := tracetest.NewInMemoryExporter()
+	provider := recordSpans(t, "contracttests", spans)
+	apartments.MakeNatsHandler(&apartmentsService{err: apartments.ErrDatabase}, nc, provider, apartments.EndpointMetrics{})
+	client := booking.NewApartmentsRepository(nc, provider, contracts.ContentTypeJSON)
+
+	if _, err := client.GetApartmentByID(context.Background(), "guest", "id"); err == nil {
+		t.Fatal("got no error")
+	}
+	recorded := waitForSpans(t, spans, 2)
+	for _, kind := range []trace.SpanKind{trace.SpanKindClient, trace.SpanKindServer} {
+		if span := spanOfKind(recorded, kind); span.Status.Code != codes.Error || len(span.Events) == 0 {
+			t.Errorf("%s span status %v and events %v, want an error", kind, span.Status, span.Events)
+		}
+	}
+}
+
+// TestTraceEnvelopeOfOlderPublishers sends a request the way services did before trace headers, without headers
+// and with the trace context in a JSON envelope around the data.
+func TestTraceEnvelopeOfOlderPublishers(t *testing.T) {
+	nc := connect(t)
+	spans := tracetest.NewInMemoryExporter()
+	apartment := testApartment("owner", apartments.StatusPublished)
+	service := &apartmentsService{apartments: []apartments.Apartment{apartment}}
+	apartments.MakeNatsHandler(service, nc, recordSpans(t, "apartments", spans), apartments.EndpointMetrics{},
+		nats_tracing.CompatEnvelope(true))
+
+	data, err := json.Marshal(contracts.GetApartmentByIDRequest{ApartmentID: apartment.ID.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// natszipkin sent 64 bit trace ids.
+	envelope, err := json.Marshal(map[string]interface{}{
+		"natsSpanContextB3Map": map[string]string{"x-b3-traceid": "000000000000002a", "x-b3-spanid": "0000000000000007", "x-b3-sampled": "1"},
+		"data":                 data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := nc.Request(contracts.SubjectGetApartmentByID, envelope, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := contracts.DecodeGetApartmentByIDResponse(context.Background(), reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := response.(contracts.GetApartmentByIDResponse); got.Err != nil || got.Apartment == nil {
+		t.Fatalf("got %+v, want the apartment", got)
+	}
+	serverSpan := spanOfKind(waitForSpans(t, spans, 1), trace.SpanKindServer)
+	if got := serverSpan.SpanContext.TraceID().String(); got != "0000000000000000000000000000002a" {
+		t.Errorf("got trace %s, want 2a", got)
+	}
+	if got := serverSpan.Parent.SpanID().String(); got != "0000000000000007" {
+		t.Errorf("got parent %s, want 7", got)
+	}
+}
+
+// TestHTTPSpanIsParentOfNATSRequest books an apartment over HTTP, in a trace begun by the gateway, and checks that
+// the request for the apartment is a child of the HTTP span.
+func TestHTTPSpanIsParentOfNATSRequest(t *testing.T) {
+	nc := connect(t)
+	spans := tracetest.NewInMemoryExporter()
+	provider := recordSpans(t, "contracttests", spans)
+	apartment := testApartment("owner", apartments.StatusPublished)
+	apartments.MakeNatsHandler(&apartmentsService{apartments: []apartments.Apartment{apartment}}, nc, provider, apartments.EndpointMetrics{})
+	service := &bookingOverNATS{apartments: booking.NewApartmentsRepository(nc, provider, contracts.ContentTypeJSON)}
+	users, err := auth.NewVerifier(base64.StdEncoding.EncodeToString([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(booking.MakeHTTPHandler(service, log.NewNopLogger(), provider, booking.EndpointMetrics{}, users))
+	defer server.Close()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, booking.UserClaim{ID: "guest"}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"apartmentId": "` + apartment.ID.Hex() + `", "start": "2030-01-01", "end": "2030-01-03"}`
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/reservations", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The gateway is instrumented with Zipkin.
+	req.Header.Set("X-B3-TraceId", "000000000000002a")
+	req.Header.Set("X-B3-SpanId", "0000000000000007")
+	req.Header.Set("X-B3-Sampled", "1")
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", res.StatusCode)
+	}
+
+	recorded := waitForSpans(t, spans, 3)
+	httpSpan, clientSpan := spanNamed(recorded, "POST /reservations"), spanOfKind(recorded, trace.SpanKindClient)
+	traceID, parentID := httpSpan.SpanContext.TraceID().String(), httpSpan.Parent.SpanID().String()
+	if traceID != "0000000000000000000000000000002a" || parentID != "0000000000000007" {
+		t.Errorf("http span of trace %s with parent %s is not a child of the gateway span",
+			httpSpan.SpanContext.TraceID(), httpSpan.Parent.SpanID())
+	}
+	if clientSpan.SpanContext.TraceID() != httpSpan.SpanContext.TraceID() || clientSpan.Parent.SpanID() != httpSpan.SpanContext.SpanID() {
+		t.Errorf("nats request span %s is not a child of the http span %s", clientSpan.SpanContext.SpanID(), httpSpan.SpanContext.SpanID())
+	}
+}
+
+// bookingOverNATS books apartments it requests from the apartments service, the rest of its methods are not used.
+type bookingOverNATS struct {
+	booking.Service
+	apartments booking.ApartmentsRepository
+}
+
+func (s *bookingOverNATS) BookApartment(ctx context.Context, userID, id string, start, end time.Time) (*booking.Reservation, error) {
+	apartment, err := s.apartments.GetApartmentByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return booking.NewReservation(apartment, userID, start, end), nil
+}
@@ -0,0 +1,183 @@
+// Package health serves the liveness and the readiness of services, the readiness being the state of the
+// dependencies they can not serve requests without.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Statuses of the service and of its dependencies.
+const (
+	StatusOK           = "ok"
+	StatusReady        = "ready"
+	StatusNotReady     = "not ready"
+	StatusShuttingDown = "shutting down"
+	StatusUp           = "up"
+	StatusDown         = "down"
+)
+
+// DefaultTimeout is the time given to every check of the readiness.
+const DefaultTimeout = 2 * time.Second
+
+// ErrTimeout is the error of checks which did not finish in time.
+var ErrTimeout = errors.New("check timed out")
+
+// Check checks a dependency, it fails when the dependency can not be used.
+type Check func(ctx context.Context) error
+
+// Health checks the dependencies of a service. Its zero value is not ready to use, see New.
+type Health struct {
+	timeout      time.Duration
+	names        []string
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+// New makes a Health giving every check timeout to finish.
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add makes the service ready only while check of the dependency name succeeds. Checks are added before the
+// handlers serve requests.
+func (h *Health) Add(name string, check Check) {
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+	}
+	h.checks[name] = check
+}
+
+// ShutDown makes the service not ready for good, so that it is taken out of rotation while it shuts down.
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Dependency is the state of a dependency.
+type Dependency struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the readiness of the service and the state of its dependencies.
+type Report struct {
+	Status       string                `json:"status"`
+	Dependencies map[string]Dependency `json:"dependencies,omitempty"`
+}
+
+// Ready runs the checks concurrently, the service is ready when all succeed and it is not shutting down.
+func (h *Health) Ready(ctx context.Context) Report {
+	if h.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = Report{Status: StatusReady, Dependencies: make(map[string]Dependency, len(h.names))}
+	)
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			dependency := Dependency{Status: StatusUp}
+			if err := run(ctx, check); err != nil {
+				dependency = Dependency{Status: StatusDown, Error: err.Error()}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = dependency
+			if dependency.Status == StatusDown {
+				report.Status = StatusNotReady
+			}
+		}(name, h.checks[name])
+	}
+	wg.Wait()
+	return report
+}
+
+// run gives up on checks ignoring the deadline of the context.
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
+
+// LiveHandler answers while the process is able to serve HTTP requests, whatever its dependencies.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// ReadyHandler answers with the report of Ready, with the status 503 Service Unavailable unless the service
+// is ready.
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Ready(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusReady {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// Mongo pings the primary of the MongoDB deployment.
+func Mongo(client *mongo.Client) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	}
+}
+
+// NATS checks that the connection to NATS is established, it is not while reconnecting.
+func NATS(nc *nats.Conn) Check {
+	return func(context.Context) error {
+		if status := nc.Status(); status != nats.CONNECTED {
+			return fmt.Errorf("connection is %s", statusName(status))
+		}
+		return nil
+	}
+}
+
+func statusName(status nats.Status) string {
+	switch status {
+	case nats.DISCONNECTED:
+		return "disconnected"
+	case nats.CLOSED:
+		return "closed"
+	case nats.RECONNECTING:
+		return "reconnecting"
+	case nats.CONNECTING:
+		return "connecting"
+	case nats.DRAINING_SUBS, nats.DRAINING_PUBS:
+		return "draining"
+	}
+	return "not connected"
+}
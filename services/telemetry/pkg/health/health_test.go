@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func up(context.Context) error { return nil }
+
+func down(context.Context) error { return errors.New("connection refused") }
+
+// hanging ignores the deadline of the context, like drivers stuck on a dead connection.
+func hanging(context.Context) error {
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestReadyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		shutDown   bool
+		wantCode   int
+		wantStatus string
+		wantDown   map[string]string
+		wantUp     []string
+	}{
+		{name: "no dependencies", wantCode: http.StatusOK, wantStatus: StatusReady},
+		{
+			name:       "ready",
+			checks:     map[string]Check{"mongo": up, "nats": up},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusReady,
+			wantUp:     []string{"mongo", "nats"},
+		},
+		{
+			name:       "not ready",
+			checks:     map[string]Check{"mongo": down, "nats": up},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusNotReady,
+			wantDown:   map[string]string{"mongo": "connection refused"},
+			wantUp:     []string{"nats"},
+		},
+		{
+			name:       "timed out",
+			checks:     map[string]Check{"apartments": hanging, "nats": up},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusNotReady,
+			wantDown:   map[string]string{"apartments": ErrTimeout.Error()},
+			wantUp:     []string{"nats"},
+		},
+		{
+			name:       "shutting down",
+			checks:     map[string]Check{"mongo": up, "nats": up},
+			shutDown:   true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusShuttingDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(50 * time.Millisecond)
+			for name, check := range tt.checks {
+				h.Add(name, check)
+			}
+			if tt.shutDown {
+				h.ShutDown()
+			}
+
+			recorder := httptest.NewRecorder()
+			h.ReadyHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			var report Report
+			if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+
+			if recorder.Code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Errorf("got %d %q, want %d %q", recorder.Code, report.Status, tt.wantCode, tt.wantStatus)
+			}
+			if len(report.Dependencies) != len(tt.wantDown)+len(tt.wantUp) {
+				t.Errorf("got dependencies %+v", report.Dependencies)
+			}
+			for name, wantErr := range tt.wantDown {
+				if got := report.Dependencies[name]; got.Status != StatusDown || got.Error != wantErr {
+					t.Errorf("%s is %+v, want down with %q", name, got, wantErr)
+				}
+			}
+			for _, name := range tt.wantUp {
+				if got := report.Dependencies[name]; got.Status != StatusUp || got.Error != "" {
+					t.Errorf("%s is %+v, want up", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLiveHandler(t *testing.T) {
+	h := New(DefaultTimeout)
+	h.Add("mongo", down)
+	h.ShutDown()
+
+	recorder := httptest.NewRecorder()
+	h.LiveHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("got %d, want 200 whatever the dependencies", recorder.Code)
+	}
+}
+
+func TestAddReplacesCheck(t *testing.T) {
+	h := New(DefaultTimeout)
+	h.Add("mongo", down)
+	h.Add("mongo", up)
+
+	report := h.Ready(context.Background())
+	if report.Status != StatusReady || len(report.Dependencies) != 1 {
+		t.Errorf("got %+v, want the one replaced check up", report)
+	}
+}
+
+func TestNATSNotConnected(t *testing.T) {
+	if err := NATS(&nats.Conn{})(context.Background()); err == nil || err.Error() != "connection is disconnected" {
+		t.Errorf("got %v, want the connection disconnected", err)
+	}
+}
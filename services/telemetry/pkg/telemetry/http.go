@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the OpenTelemetry scope of the spans of this package.
+const instrumentationName = "telemetry/pkg/telemetry"
+
+// Span attributes of HTTP requests.
+const (
+	AttributeHTTPMethod     = "http.request.method"
+	AttributeHTTPRoute      = "http.route"
+	AttributeHTTPStatusCode = "http.response.status_code"
+	AttributeURLPath        = "url.path"
+)
+
+// HTTPServerTrace traces the requests of a go-kit HTTP server with a server span, a child of the span context
+// the client sent along, so that traces begun by the gateway go on through the services. Spans are named after
+// the method and the path template of the route serving the request, like POST /reservations. As server errors
+// only responses with a 5xx status fail the span.
+func HTTPServerTrace(provider trace.TracerProvider) kithttp.ServerOption {
+	tracer := provider.Tracer(instrumentationName)
+
+	serverBefore := kithttp.ServerBefore(func(ctx context.Context, r *http.Request) context.Context {
+		ctx = Propagator.Extract(ctx, propagation.HeaderCarrier(r.Header))
+		name := r.Method
+		attributes := []attribute.KeyValue{
+			attribute.String(AttributeHTTPMethod, r.Method),
+			attribute.String(AttributeURLPath, r.URL.Path),
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				name = r.Method + " " + template
+				attributes = append(attributes, attribute.String(AttributeHTTPRoute, template))
+			}
+		}
+		ctx, _ = tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+		return ctx
+	})
+
+	finalizer := kithttp.ServerFinalizer(func(ctx context.Context, code int, r *http.Request) {
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(attribute.Int(AttributeHTTPStatusCode, code))
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(code))
+		}
+		span.End()
+	})
+
+	return func(server *kithttp.Server) {
+		serverBefore(server)
+		finalizer(server)
+	}
+}
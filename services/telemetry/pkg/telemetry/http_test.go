@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestHTTPServerTrace(t *testing.T) {
+	const (
+		traceID = "0af7651916cd43dd8448eb211c80319c"
+		spanID  = "b7ad6b7169203331"
+	)
+	tests := []struct {
+		name       string
+		path       string
+		header     http.Header
+		wantParent string
+		wantStatus codes.Code
+	}{
+		{name: "root", path: "/reservations/200"},
+		{
+			name:       "w3c parent",
+			path:       "/reservations/200",
+			header:     http.Header{"Traceparent": {"00-" + traceID + "-" + spanID + "-01"}},
+			wantParent: spanID,
+		},
+		{
+			name:       "b3 parent",
+			path:       "/reservations/200",
+			header:     http.Header{"X-B3-Traceid": {traceID}, "X-B3-Spanid": {spanID}, "X-B3-Sampled": {"1"}},
+			wantParent: spanID,
+		},
+		{name: "client error", path: "/reservations/404"},
+		{name: "server error", path: "/reservations/500", wantStatus: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, spans := recordSpans(t)
+			r := mux.NewRouter()
+			r.Methods(http.MethodGet).Path("/reservations/{code}").Handler(kithttp.NewServer(
+				func(ctx context.Context, request interface{}) (interface{}, error) {
+					code := request.(int)
+					if code == http.StatusInternalServerError {
+						return nil, errors.New("database down")
+					}
+					return code, nil
+				},
+				func(_ context.Context, r *http.Request) (interface{}, error) {
+					return strconv.Atoi(mux.Vars(r)["code"])
+				},
+				func(_ context.Context, w http.ResponseWriter, response interface{}) error {
+					w.WriteHeader(response.(int))
+					return nil
+				},
+				HTTPServerTrace(provider),
+			))
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			recorded := spans.GetSpans()
+			if len(recorded) != 1 {
+				t.Fatalf("got %d spans, want 1", len(recorded))
+			}
+			span := recorded[0]
+			if span.Name != "GET /reservations/{code}" || attributeOf(span, AttributeHTTPRoute) != "/reservations/{code}" {
+				t.Errorf("got span %q of route %q", span.Name, attributeOf(span, AttributeHTTPRoute))
+			}
+			if got := attributeOf(span, AttributeHTTPStatusCode); got != tt.path[len("/reservations/"):] {
+				t.Errorf("got status code %s", got)
+			}
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("got span status %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+			switch {
+			case tt.wantParent == "" && span.Parent.IsValid():
+				t.Errorf("got parent %s, want a root span", span.Parent.SpanID())
+			case tt.wantParent != "" && (span.Parent.SpanID().String() != tt.wantParent || span.SpanContext.TraceID().String() != traceID):
+				t.Errorf("got span of trace %s with parent %s", span.SpanContext.TraceID(), span.Parent.SpanID())
+			}
+		})
+	}
+}
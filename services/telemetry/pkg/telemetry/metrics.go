@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/kit/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// NewCounter makes a go-kit counter recording to the OpenTelemetry counter of the given name. Label values
+// set with With become attributes of the measurements. It panics if the meter refuses the counter.
+func (t *Telemetry) NewCounter(name, description string) metrics.Counter {
+	counter, err := t.meter.Float64Counter(name, metric.WithDescription(description))
+	if err != nil {
+		panic(err)
+	}
+	return &otelCounter{counter: counter}
+}
+
+// NewHistogram makes a go-kit histogram recording to the OpenTelemetry histogram of the given name, with the
+// default buckets of the exporter unless others are given. It panics if the meter refuses the histogram.
+func (t *Telemetry) NewHistogram(name, description, unit string, buckets ...float64) metrics.Histogram {
+	options := []metric.Float64HistogramOption{metric.WithDescription(description), metric.WithUnit(unit)}
+	if len(buckets) > 0 {
+		options = append(options, metric.WithExplicitBucketBoundaries(buckets...))
+	}
+	histogram, err := t.meter.Float64Histogram(name, options...)
+	if err != nil {
+		panic(err)
+	}
+	return &otelHistogram{histogram: histogram}
+}
+
+type otelCounter struct {
+	counter metric.Float64Counter
+	labels  labelValues
+}
+
+func (c *otelCounter) With(labelValues ...string) metrics.Counter {
+	return &otelCounter{counter: c.counter, labels: c.labels.with(labelValues)}
+}
+
+func (c *otelCounter) Add(delta float64) {
+	c.counter.Add(context.Background(), delta, metric.WithAttributeSet(c.labels.attributes()))
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	labels    labelValues
+}
+
+func (h *otelHistogram) With(labelValues ...string) metrics.Histogram {
+	return &otelHistogram{histogram: h.histogram, labels: h.labels.with(labelValues)}
+}
+
+func (h *otelHistogram) Observe(value float64) {
+	h.histogram.Record(context.Background(), value, metric.WithAttributeSet(h.labels.attributes()))
+}
+
+// labelValues are the label name and value pairs of go-kit metrics, a missing value is "unknown" as in go-kit.
+type labelValues []string
+
+func (lvs labelValues) with(pairs []string) labelValues {
+	if len(pairs)%2 != 0 {
+		pairs = append(pairs, "unknown")
+	}
+	return append(append(make(labelValues, 0, len(lvs)+len(pairs)), lvs...), pairs...)
+}
+
+func (lvs labelValues) attributes() attribute.Set {
+	attributes := make([]attribute.KeyValue, 0, len(lvs)/2)
+	for i := 0; i < len(lvs); i += 2 {
+		attributes = append(attributes, attribute.String(lvs[i], lvs[i+1]))
+	}
+	return attribute.NewSet(attributes...)
+}
+
+// LatencyBuckets are the bucket boundaries of request durations in seconds, from 5ms to 10s.
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Error classes label requests with the kind of error they failed with. The classes of NATS replies are the
+// error codes of contracts, the others are named alike.
+const (
+	ErrorClassNone             = "none"
+	ErrorClassInvalidArgument  = "invalid_argument"
+	ErrorClassUnauthenticated  = "unauthenticated"
+	ErrorClassPermissionDenied = "permission_denied"
+	ErrorClassNotFound         = "not_found"
+	ErrorClassConflict         = "conflict"
+	ErrorClassUnavailable      = "unavailable"
+	ErrorClassInternal         = "internal"
+)
+
+// ErrorClass classifies a request by the HTTP status code it is answered with.
+func ErrorClass(status int) string {
+	switch {
+	case status < http.StatusBadRequest:
+		return ErrorClassNone
+	case status == http.StatusUnauthorized:
+		return ErrorClassUnauthenticated
+	case status == http.StatusForbidden:
+		return ErrorClassPermissionDenied
+	case status == http.StatusNotFound:
+		return ErrorClassNotFound
+	case status == http.StatusConflict:
+		return ErrorClassConflict
+	case status == http.StatusServiceUnavailable:
+		return ErrorClassUnavailable
+	case status < http.StatusInternalServerError:
+		return ErrorClassInvalidArgument
+	}
+	return ErrorClassInternal
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes of MongoDB commands.
+const (
+	AttributeDBSystem     = "db.system"
+	AttributeDBName       = "db.name"
+	AttributeDBOperation  = "db.operation"
+	AttributeDBCollection = "db.mongodb.collection"
+)
+
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
+// MongoMonitor traces the commands sent to MongoDB as client spans, children of the span in the context of
+// the call. Commands outside of a traced request, like the creation of indexes on start, are not traced.
+func MongoMonitor(provider trace.TracerProvider) *event.CommandMonitor {
+	tracer := provider.Tracer(instrumentationName)
+	var spans sync.Map
+
+	end := func(key commandKey, failure string) {
+		value, ok := spans.LoadAndDelete(key)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		if failure != "" {
+			span.SetStatus(codes.Error, failure)
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				return
+			}
+			attributes := []attribute.KeyValue{
+				attribute.String(AttributeDBSystem, "mongodb"),
+				attribute.String(AttributeDBName, evt.DatabaseName),
+				attribute.String(AttributeDBOperation, evt.CommandName),
+			}
+			// The collection is the value of the first element of commands on collections, like find or insert.
+			if element, err := evt.Command.IndexErr(0); err == nil {
+				if collection, ok := element.Value().StringValueOK(); ok {
+					attributes = append(attributes, attribute.String(AttributeDBCollection, collection))
+				}
+			}
+			_, span := tracer.Start(ctx, evt.CommandName, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+			spans.Store(commandKey{evt.ConnectionID, evt.RequestID}, span)
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			end(commandKey{evt.ConnectionID, evt.RequestID}, "")
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			end(commandKey{evt.ConnectionID, evt.RequestID}, evt.Failure)
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMongoMonitor(t *testing.T) {
+	command, err := bson.Marshal(bson.D{{Key: "find", Value: "apartments"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		traced     bool
+		failure    string
+		wantSpans  int
+		wantStatus codes.Code
+	}{
+		{name: "outside of a request"},
+		{name: "succeeded", traced: true, wantSpans: 2},
+		{name: "failed", traced: true, failure: "connection reset", wantSpans: 2, wantStatus: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, spans := recordSpans(t)
+			monitor := MongoMonitor(provider)
+			ctx := context.Background()
+			if tt.traced {
+				ctx, _ = provider.Tracer("test").Start(ctx, "get apartment")
+			}
+
+			monitor.Started(ctx, &event.CommandStartedEvent{
+				Command: command, DatabaseName: "apartments", CommandName: "find", RequestID: 1, ConnectionID: "c",
+			})
+			finished := event.CommandFinishedEvent{CommandName: "find", RequestID: 1, ConnectionID: "c"}
+			if tt.failure != "" {
+				monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished, Failure: tt.failure})
+			} else {
+				monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished})
+			}
+			if tt.traced {
+				trace.SpanFromContext(ctx).End()
+			}
+
+			recorded := spans.GetSpans()
+			if len(recorded) != tt.wantSpans {
+				t.Fatalf("got %d spans, want %d", len(recorded), tt.wantSpans)
+			}
+			if tt.wantSpans == 0 {
+				return
+			}
+			span, parent := recorded[0], recorded[1]
+			if span.Name != "find" || span.Parent.SpanID() != parent.SpanContext.SpanID() {
+				t.Errorf("got span %q with parent %s, want find in the request span", span.Name, span.Parent.SpanID())
+			}
+			if attributeOf(span, AttributeDBCollection) != "apartments" || attributeOf(span, AttributeDBName) != "apartments" {
+				t.Errorf("got attributes %v", span.Attributes)
+			}
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("got status %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+		})
+	}
+}
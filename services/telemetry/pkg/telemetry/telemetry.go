@@ -0,0 +1,196 @@
+// Package telemetry sets up tracing and metrics shared by the services. Spans are made with OpenTelemetry,
+// whose trace context is sent in W3C and B3 headers, and exported to Zipkin, an OTLP collector or the
+// standard output. Metrics are go-kit metrics recorded by OpenTelemetry instruments.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/otlptranslator"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporters of spans and metrics.
+const (
+	// ExporterZipkin sends spans to the Zipkin HTTP API.
+	ExporterZipkin = "zipkin"
+	// ExporterOTLP sends spans or metrics to an OpenTelemetry collector over OTLP/HTTP.
+	ExporterOTLP = "otlp"
+	// ExporterStdout prints spans or metrics to the standard output, meant for local runs.
+	ExporterStdout = "stdout"
+	// ExporterPrometheus exposes metrics to be scraped from the default Prometheus registry.
+	ExporterPrometheus = "prometheus"
+	// ExporterNone drops spans or metrics.
+	ExporterNone = "none"
+)
+
+var ErrUnknownExporter = errors.New("unknown telemetry exporter")
+
+// Propagator reads and writes the trace context of requests, in W3C traceparent headers and in the B3 headers
+// of Zipkin instrumentation. Both are written, the W3C header is read first.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)),
+)
+
+// Config selects the exporters of a service.
+type Config struct {
+	ServiceName string
+	// HostPort is the address of the service in spans.
+	HostPort string
+	// SpanExporter replaces the exporter selected by Traces and exports every span as soon as it ends, tests
+	// record spans with a tracetest.InMemoryExporter.
+	SpanExporter sdktrace.SpanExporter
+	// Traces is the exporter of spans: zipkin, otlp, stdout or none.
+	Traces string
+	// Metrics is the exporter of metrics: prometheus, otlp, stdout or none.
+	Metrics string
+	// ZipkinURL is the span endpoint of the Zipkin HTTP API.
+	ZipkinURL string
+	// OTLPEndpoint is the base URL of the collector, by default OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318.
+	OTLPEndpoint string
+}
+
+// TraceExporters and MetricExporters are the valid values of Config.Traces and Config.Metrics.
+var (
+	TraceExporters  = []string{ExporterZipkin, ExporterOTLP, ExporterStdout, ExporterNone}
+	MetricExporters = []string{ExporterPrometheus, ExporterOTLP, ExporterStdout, ExporterNone}
+)
+
+// Telemetry is the tracer provider and the meter of a service.
+type Telemetry struct {
+	TracerProvider trace.TracerProvider
+	meter          metric.Meter
+	shutdown       []func(ctx context.Context) error
+}
+
+// Setup makes the tracer provider and the meter of the service with the exporters of config.
+func Setup(ctx context.Context, config Config) (*Telemetry, error) {
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(
+			attribute.String("service.name", config.ServiceName),
+			attribute.String("service.instance.id", config.HostPort),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Telemetry{
+		TracerProvider: tracenoop.NewTracerProvider(),
+		meter:          metricnoop.NewMeterProvider().Meter(config.ServiceName),
+	}
+	spanProcessor, err := newSpanProcessor(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if spanProcessor != nil {
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanProcessor), sdktrace.WithResource(res))
+		t.TracerProvider = provider
+		t.shutdown = append(t.shutdown, provider.Shutdown)
+	}
+
+	reader, err := newMetricReader(ctx, config)
+	if err != nil {
+		_ = t.Shutdown(ctx)
+		return nil, err
+	}
+	if reader != nil {
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+		t.meter = provider.Meter(config.ServiceName)
+		t.shutdown = append(t.shutdown, provider.Shutdown)
+	}
+	return t, nil
+}
+
+// Shutdown flushes the spans and the metrics not exported yet.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	var err error
+	for _, shutdown := range t.shutdown {
+		if shutdownErr := shutdown(ctx); err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
+}
+
+// newSpanProcessor returns no processor when spans are dropped.
+func newSpanProcessor(ctx context.Context, config Config) (sdktrace.SpanProcessor, error) {
+	if config.SpanExporter != nil {
+		return sdktrace.NewSimpleSpanProcessor(config.SpanExporter), nil
+	}
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch config.Traces {
+	case ExporterZipkin:
+		exporter, err = zipkin.New(config.ZipkinURL)
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if config.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.OTLPEndpoint+"/v1/traces"))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w %q for traces", ErrUnknownExporter, config.Traces)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewBatchSpanProcessor(exporter), nil
+}
+
+// newMetricReader returns no reader when metrics are dropped.
+func newMetricReader(ctx context.Context, config Config) (sdkmetric.Reader, error) {
+	switch config.Metrics {
+	case ExporterPrometheus:
+		// Metric names are kept as they are given, the same names the services exposed before.
+		return prometheus.New(
+			prometheus.WithTranslationStrategy(otlptranslator.UnderscoreEscapingWithoutSuffixes),
+			prometheus.WithoutScopeInfo(),
+			prometheus.WithoutTargetInfo(),
+		)
+	case ExporterOTLP:
+		var options []otlpmetrichttp.Option
+		if config.OTLPEndpoint != "" {
+			options = append(options, otlpmetrichttp.WithEndpointURL(config.OTLPEndpoint+"/v1/metrics"))
+		}
+		metricExporter, err := otlpmetrichttp.New(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		return sdkmetric.NewPeriodicReader(metricExporter), nil
+	case ExporterStdout:
+		metricExporter, err := stdoutmetric.New()
+		if err != nil {
+			return nil, err
+		}
+		return sdkmetric.NewPeriodicReader(metricExporter), nil
+	case ExporterNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w %q for metrics", ErrUnknownExporter, config.Metrics)
+}
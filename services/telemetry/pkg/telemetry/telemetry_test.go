@@ -0,0 +1,29 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans returns the tracer provider of a service exporting its spans to the returned exporter.
+func recordSpans(t *testing.T) (trace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tel, err := Setup(context.Background(), Config{ServiceName: "test", SpanExporter: exporter, Metrics: ExporterNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tel.TracerProvider, exporter
+}
+
+func attributeOf(span tracetest.SpanStub, key string) string {
+	for _, attribute := range span.Attributes {
+		if string(attribute.Key) == key {
+			return attribute.Value.Emit()
+		}
+	}
+	return ""
+}
@@ -25,4 +25,7 @@ public class UserEntity {
 
   private byte[] hash;
   private byte[] salt;
+
+  // Role is granted in the database, "admin" lets the user moderate apartments.
+  private String role;
 }
@@ -3,5 +3,6 @@ package com.example.springweb.services.userservice;
 public class JwtClaim {
   public Long id;
   public String email;
+  public String role;
   public int exp;
 }
@@ -22,6 +22,9 @@ public class JwtEncoder {
     HashMap<String, Object> claim = new HashMap<>();
     claim.put("id", userEntity.getId().toString());
     claim.put("email", userEntity.getEmail());
+    if (userEntity.getRole() != null) {
+      claim.put("role", userEntity.getRole());
+    }
 
     Date expiration = new Date(System.currentTimeMillis() + JWT_TOKEN_VALIDITY * 1000);
     JwtTokenDto jwtTokenDto = new JwtTokenDto();
@@ -38,6 +41,7 @@ public class JwtEncoder {
       JwtClaim jwtClaim = new JwtClaim();
       jwtClaim.id = Long.valueOf(parse.get("id", String.class));
       jwtClaim.email = parse.get("email", String.class);
+      jwtClaim.role = parse.get("role", String.class);
       return jwtClaim;
     } catch (Exception e) {
       return null;